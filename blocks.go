@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// A block works both ways: neither user sees the other's chirps, and neither
+// can follow, reply to, quote, rechirp or like the other. Blocking someone
+// also ends any follows between the two, and their @mentions of you stop
+// notifying you. A mute only hides the muted user's chirps and notifications
+// from you. The hidden_authors view is who each user doesn't see, and every
+// chirp listing leaves those authors out for signed-in callers.
+
+// Blanks chirps by authors the viewer doesn't see. Lists already leave them
+// out; this covers single chirps, threads and what rechirps and quotes embed.
+func (cfg *apiConfig) hideChirps(ctx context.Context, viewer uuid.UUID, chirps []*Chirp) error {
+	hiddenIDs, err := cfg.dbQuerries.ListHiddenAuthorIDs(ctx, viewer)
+	if err != nil || len(hiddenIDs) == 0 {
+		return err
+	}
+	hidden := map[uuid.UUID]bool{}
+	for _, id := range hiddenIDs {
+		hidden[id] = true
+	}
+	for _, chirp := range chirps {
+		if chirp.Original != nil && hidden[chirp.Original.UserID] {
+			hideChirp(chirp.Original)
+		}
+		if hidden[chirp.UserID] {
+			hideChirp(chirp)
+		}
+	}
+	return nil
+}
+
+func hideChirp(chirp *Chirp) {
+	chirp.Body = ""
+	chirp.Original = nil
+	chirp.Hidden = true
+}
+
+// Whether the user is blocked from interacting with a chirp, either way round
+func isBlockedFromChirp(ctx context.Context, q *database.Queries, userID, chirpID uuid.UUID) (bool, error) {
+	return q.IsBlockedFromChirp(ctx, database.IsBlockedFromChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+}
+
+func (cfg *apiConfig) blockUser(w http.ResponseWriter, r *http.Request) {
+	blockedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad user ID")
+		return
+	}
+	blockerID := requestPrincipal(r).UserID
+	if blockedID == blockerID {
+		respondWithError(w, 400, "You can't block yourself")
+		return
+	}
+
+	ctx := context.Background()
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, 500, "Unable to block user")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	_, err = qtx.GetUserById(ctx, blockedID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: loading user %v: %v", blockedID, err)
+		respondWithError(w, 500, "Unable to block user")
+		return
+	}
+	created, err := qtx.CreateBlock(ctx, database.CreateBlockParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		log.Printf("ERROR: blocking %v: %v", blockedID, err)
+		respondWithError(w, 500, "Unable to block user")
+		return
+	}
+	if created == 0 {
+		// Already blocked
+		w.WriteHeader(204)
+		return
+	}
+	err = removeFollow(ctx, qtx, blockerID, blockedID)
+	if err == nil {
+		err = removeFollow(ctx, qtx, blockedID, blockerID)
+	}
+	if err != nil {
+		log.Printf("ERROR: removing follows on blocking %v: %v", blockedID, err)
+		respondWithError(w, 500, "Unable to block user")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Unable to block user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// Unblocking doesn't bring back the follows the block ended
+func (cfg *apiConfig) unblockUser(w http.ResponseWriter, r *http.Request) {
+	blockedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad user ID")
+		return
+	}
+	_, err = cfg.dbQuerries.DeleteBlock(context.Background(), database.DeleteBlockParams{
+		BlockerID: requestPrincipal(r).UserID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		log.Printf("ERROR: unblocking %v: %v", blockedID, err)
+		respondWithError(w, 500, "Unable to unblock user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) muteUser(w http.ResponseWriter, r *http.Request) {
+	mutedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad user ID")
+		return
+	}
+	muterID := requestPrincipal(r).UserID
+	if mutedID == muterID {
+		respondWithError(w, 400, "You can't mute yourself")
+		return
+	}
+
+	ctx := context.Background()
+	_, err = cfg.dbQuerries.GetUserById(ctx, mutedID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: loading user %v: %v", mutedID, err)
+		respondWithError(w, 500, "Unable to mute user")
+		return
+	}
+	_, err = cfg.dbQuerries.CreateMute(ctx, database.CreateMuteParams{
+		MuterID: muterID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		log.Printf("ERROR: muting %v: %v", mutedID, err)
+		respondWithError(w, 500, "Unable to mute user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) unmuteUser(w http.ResponseWriter, r *http.Request) {
+	mutedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad user ID")
+		return
+	}
+	_, err = cfg.dbQuerries.DeleteMute(context.Background(), database.DeleteMuteParams{
+		MuterID: requestPrincipal(r).UserID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		log.Printf("ERROR: unmuting %v: %v", mutedID, err)
+		respondWithError(w, 500, "Unable to unmute user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// Same shape as a chirp cursor, keyed on when the user was blocked or muted
+func encodeListedUserCursor(user ListedUser) string {
+	data, _ := json.Marshal(chirpCursor{CreatedAt: user.Since, ID: user.UserID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (cfg *apiConfig) listBlocks(w http.ResponseWriter, r *http.Request) {
+	cfg.listHiddenUsers(w, r, "blocks", func(ctx context.Context, params database.ListBlocksParams) ([]ListedUser, error) {
+		rows, err := cfg.dbQuerries.ListBlocks(ctx, params)
+		users := []ListedUser{}
+		for _, row := range rows {
+			users = append(users, ListedUser{UserID: row.UserID, Since: row.CreatedAt})
+		}
+		return users, err
+	})
+}
+
+func (cfg *apiConfig) listMutes(w http.ResponseWriter, r *http.Request) {
+	cfg.listHiddenUsers(w, r, "mutes", func(ctx context.Context, params database.ListBlocksParams) ([]ListedUser, error) {
+		rows, err := cfg.dbQuerries.ListMutes(ctx, database.ListMutesParams(params))
+		users := []ListedUser{}
+		for _, row := range rows {
+			users = append(users, ListedUser{UserID: row.UserID, Since: row.CreatedAt})
+		}
+		return users, err
+	})
+}
+
+// A page of who the caller has blocked or muted, most recent first
+func (cfg *apiConfig) listHiddenUsers(w http.ResponseWriter, r *http.Request, list string, fetch func(context.Context, database.ListBlocksParams) ([]ListedUser, error)) {
+	userID := requestPrincipal(r).UserID
+	query := r.URL.Query()
+	limit, err := pageSize(query)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	params := database.ListBlocksParams{
+		UserID:     userID,
+		MaxResults: limit + 1,
+	}
+	if cursorParam := query.Get("cursor"); cursorParam != "" {
+		cursor, err := decodeChirpCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, 400, err.Error())
+			return
+		}
+		params.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	users, err := fetch(context.Background(), params)
+	if err != nil {
+		log.Printf("ERROR: listing %v of %v: %v", list, userID, err)
+		respondWithError(w, 500, "Unable to get "+list)
+		return
+	}
+	if len(users) > int(limit) {
+		users = users[:limit]
+		setNextPage(w, r, encodeListedUserCursor(users[len(users)-1]))
+	}
+	respondWithJSON(w, 200, users)
+}
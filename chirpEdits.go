@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/avgra3/chirpy/internal/entitlements"
+	"github.com/google/uuid"
+)
+
+// Authors can fix a chirp for a while after posting it. The body it had
+// before each edit is kept in chirp_revisions, and anyone who can see the
+// chirp can see them.
+
+func (cfg *apiConfig) editChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad chirp ID")
+		return
+	}
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, 500, "couldn't read request")
+		return
+	}
+	params := parameters{}
+	err = json.Unmarshal(data, &params)
+	if err != nil {
+		respondWithError(w, 400, "couldn't unmarshal parameters")
+		return
+	}
+
+	userID := requestPrincipal(r).UserID
+	ctx := context.Background()
+	author, err := cfg.dbQuerries.GetUserById(ctx, userID)
+	if err != nil {
+		respondWithError(w, 401, "User does not exist")
+		return
+	}
+	// Same limit as a new chirp
+	if !cfg.userMay(author, entitlements.ChirpLength, int64(utf8.RuneCountInString(params.Body))) {
+		errorMessage := fmt.Sprintf("Chirp is too long (limit is %v characters)", cfg.userLimit(author, entitlements.ChirpLength))
+		respondWithError(w, 400, errorMessage)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, 500, "Unable to edit chirp")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	// Locks the row, so two edits at once can't both save the same revision
+	chirp, err := qtx.GetChirpForEdit(ctx, database.GetChirpForEditParams{
+		EditWindowSeconds: durationSeconds(cfg.chirpEditWindow),
+		ID:                chirpID,
+	})
+	if err == sql.ErrNoRows || (err == nil && chirp.DeletedAt.Valid) {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: loading chirp %v for edit: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to edit chirp")
+		return
+	}
+	if chirp.UserID != userID {
+		respondWithError(w, 403, "You don't own this chirp!")
+		return
+	}
+	if chirp.RechirpOf.Valid {
+		respondWithError(w, 400, "Rechirps can't be edited")
+		return
+	}
+	if !chirp.Editable {
+		respondWithError(w, 403, fmt.Sprintf("Chirps can only be edited for %v after posting", cfg.chirpEditWindow))
+		return
+	}
+
+	err = qtx.CreateChirpRevision(ctx, database.CreateChirpRevisionParams{
+		ChirpID:   chirp.ID,
+		Body:      chirp.Body,
+		CreatedAt: chirp.UpdatedAt,
+	})
+	if err != nil {
+		log.Printf("ERROR: saving revision of %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to edit chirp")
+		return
+	}
+	edited, err := qtx.UpdateChirpBody(ctx, database.UpdateChirpBodyParams{
+		ID:   chirp.ID,
+		Body: cleanWords(params.Body),
+	})
+	if err != nil {
+		log.Printf("ERROR: editing chirp %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to edit chirp")
+		return
+	}
+	err = tagChirp(ctx, qtx, edited)
+	if err != nil {
+		log.Printf("ERROR: retagging chirp %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to edit chirp")
+		return
+	}
+	// Only people newly mentioned hear about it
+	err = notifyMentions(ctx, qtx, edited)
+	if err != nil {
+		log.Printf("ERROR: notifying about chirp %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to edit chirp")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Unable to edit chirp")
+		return
+	}
+	resp := chirpResponse(edited)
+	err = cfg.prepareChirps(ctx, r, []*Chirp{&resp})
+	if err != nil {
+		log.Printf("ERROR: loading original of %v: %v", edited.ID, err)
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+// Earlier versions of a chirp, newest first
+func (cfg *apiConfig) getChirpHistory(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad chirp ID")
+		return
+	}
+	ctx := context.Background()
+	chirp, err := cfg.dbQuerries.GetChirpByChirpID(ctx, chirpID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: loading chirp %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to get chirp history")
+		return
+	}
+	// Old bodies are hidden from the same people as the chirp itself
+	if viewer := requestPrincipal(r).UserID; viewer != uuid.Nil {
+		resp := chirpResponse(chirp)
+		err = cfg.hideChirps(ctx, viewer, []*Chirp{&resp})
+		if err != nil {
+			log.Printf("ERROR: checking blocks on %v: %v", chirpID, err)
+			respondWithError(w, 500, "Unable to get chirp history")
+			return
+		}
+		if resp.Hidden {
+			respondWithError(w, 404, "Chirp not found")
+			return
+		}
+	}
+	rows, err := cfg.dbQuerries.GetChirpRevisions(ctx, chirpID)
+	if err != nil {
+		log.Printf("ERROR: loading revisions of %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to get chirp history")
+		return
+	}
+	revisions := []ChirpRevision{}
+	for _, row := range rows {
+		revisions = append(revisions, ChirpRevision{
+			ID:         row.ID,
+			Body:       row.Body,
+			CreatedAt:  row.CreatedAt,
+			ReplacedAt: row.ReplacedAt,
+		})
+	}
+	respondWithJSON(w, 200, revisions)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	auth "github.com/avgra3/chirpy/internal/auth"
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/avgra3/chirpy/internal/mailer"
+	"github.com/google/uuid"
+)
+
+// Sends a verification token to email. On signup that's the account's own
+// email; on an email change it's the pending one.
+func (cfg *apiConfig) sendEmailVerification(ctx context.Context, userID uuid.UUID, email string) error {
+	token, tokenHash, err := auth.MakeOneTimeToken()
+	if err != nil {
+		return err
+	}
+	// Only the newest verification email should work
+	err = cfg.dbQuerries.InvalidateEmailVerifications(ctx, userID)
+	if err != nil {
+		return err
+	}
+	_, err = cfg.dbQuerries.CreateEmailVerification(ctx, database.CreateEmailVerificationParams{
+		TokenHash: tokenHash,
+		UserID:    userID,
+		Email:     email,
+	})
+	if err != nil {
+		return err
+	}
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Confirm your email for Chirpy",
+		Body: fmt.Sprintf("Please confirm this email address for your Chirpy account.\n\n"+
+			"Send this token to POST /api/users/verify-email within the next 24 hours:\n\n"+
+			"%v\n\nIf this wasn't you, you can ignore this email.\n", token),
+	})
+}
+
+func (cfg *apiConfig) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token string `json:"token"`
+	}
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, 500, "couldn't read request")
+		return
+	}
+	params := parameters{}
+	err = json.Unmarshal(data, &params)
+	if err != nil {
+		respondWithError(w, 400, "couldn't unmarshal parameters")
+		return
+	}
+
+	ctx := context.Background()
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, 500, "Unable to verify email")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	verification, err := qtx.UseEmailVerification(ctx, auth.HashOneTimeToken(params.Token))
+	if err == sql.ErrNoRows {
+		respondWithError(w, 400, "Invalid or expired token")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: using email verification: %v", err)
+		respondWithError(w, 500, "Unable to verify email")
+		return
+	}
+	// Also swaps in a pending email, which someone may have taken since
+	user, err := qtx.VerifyUserEmail(ctx, database.VerifyUserEmailParams{
+		ID:    verification.UserID,
+		Email: verification.Email,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "Email is already in use")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: verifying email: %v", err)
+		respondWithError(w, 500, "Unable to verify email")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Unable to verify email")
+		return
+	}
+	respondWithJSON(w, 200, userResponse(user))
+}
+
+func (cfg *apiConfig) resendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	userID := requestPrincipal(r).UserID
+	ctx := context.Background()
+	user, err := cfg.dbQuerries.GetUserById(ctx, userID)
+	if err != nil {
+		respondWithError(w, 404, "User does not exist")
+		return
+	}
+
+	email := user.Email
+	if user.PendingEmail.Valid {
+		email = user.PendingEmail.String
+	} else if user.EmailVerifiedAt.Valid {
+		respondWithError(w, 409, "Email is already verified")
+		return
+	}
+	err = cfg.sendEmailVerification(ctx, userID, email)
+	if err != nil {
+		log.Printf("ERROR: sending email verification: %v", err)
+		respondWithError(w, 500, "Unable to send verification email")
+		return
+	}
+	w.WriteHeader(202)
+}
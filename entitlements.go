@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/avgra3/chirpy/internal/entitlements"
+)
+
+// Handlers ask what a user may do through these helpers rather than reading
+// is_chirpy_red, so what each tier gets lives in one table (see
+// ENTITLEMENTS_FILE).
+
+func userTier(user database.User) string {
+	if user.IsChirpyRed.Bool {
+		return entitlements.TierRed
+	}
+	return entitlements.TierFree
+}
+
+// Whether the user may use amount of a feature, e.g. post a chirp of n characters
+func (cfg *apiConfig) userMay(user database.User, feature entitlements.Feature, amount int64) bool {
+	return cfg.entitlements.Allows(userTier(user), feature, amount)
+}
+
+func (cfg *apiConfig) userLimit(user database.User, feature entitlements.Feature) int64 {
+	return cfg.entitlements.Limit(userTier(user), feature)
+}
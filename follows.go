@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Follows are a row each in follows, with users.follower_count and
+// following_count changed in the same transaction. Following and unfollowing
+// are idempotent. The home timeline is the caller's own chirps and those of
+// everyone they follow, read from the cache in timelines.go.
+
+func (cfg *apiConfig) followUser(w http.ResponseWriter, r *http.Request) {
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad user ID")
+		return
+	}
+	followerID := requestPrincipal(r).UserID
+	if followeeID == followerID {
+		respondWithError(w, 400, "You can't follow yourself")
+		return
+	}
+
+	ctx := context.Background()
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, 500, "Unable to follow user")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	_, err = qtx.GetUserById(ctx, followeeID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: loading user %v: %v", followeeID, err)
+		respondWithError(w, 500, "Unable to follow user")
+		return
+	}
+	blocked, err := qtx.BlockExists(ctx, database.BlockExistsParams{
+		UserID:  followerID,
+		OtherID: followeeID,
+	})
+	if err != nil {
+		log.Printf("ERROR: checking blocks for %v: %v", followeeID, err)
+		respondWithError(w, 500, "Unable to follow user")
+		return
+	}
+	if blocked {
+		respondWithError(w, 403, "You can't follow this user")
+		return
+	}
+	created, err := qtx.CreateFollow(ctx, database.CreateFollowParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		log.Printf("ERROR: following %v: %v", followeeID, err)
+		respondWithError(w, 500, "Unable to follow user")
+		return
+	}
+	if created == 0 {
+		// Already following
+		w.WriteHeader(204)
+		return
+	}
+	err = qtx.AdjustFollowCounts(ctx, database.AdjustFollowCountsParams{
+		FolloweeID: followeeID,
+		Delta:      1,
+		FollowerID: followerID,
+	})
+	if err == nil {
+		err = qtx.NotifyFollow(ctx, database.NotifyFollowParams{
+			UserID:  followeeID,
+			ActorID: followerID,
+		})
+	}
+	// Chirps that were never pushed are merged in when the timeline is read
+	if err == nil {
+		err = qtx.BackfillTimeline(ctx, database.BackfillTimelineParams{
+			UserID:    followerID,
+			AuthorID:  followeeID,
+			PerAuthor: timelineBackfillSize,
+		})
+	}
+	if err != nil {
+		log.Printf("ERROR: following %v: %v", followeeID, err)
+		respondWithError(w, 500, "Unable to follow user")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Unable to follow user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) unfollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad user ID")
+		return
+	}
+	followerID := requestPrincipal(r).UserID
+
+	ctx := context.Background()
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, 500, "Unable to unfollow user")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	err = removeFollow(ctx, qtx, followerID, followeeID)
+	if err != nil {
+		log.Printf("ERROR: unfollowing %v: %v", followeeID, err)
+		respondWithError(w, 500, "Unable to unfollow user")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Unable to unfollow user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// Deletes a follow if there is one, taking it off both users' counts and the
+// followee's chirps off the follower's timeline
+func removeFollow(ctx context.Context, qtx *database.Queries, followerID, followeeID uuid.UUID) error {
+	deleted, err := qtx.DeleteFollow(ctx, database.DeleteFollowParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil || deleted == 0 {
+		return err
+	}
+	err = qtx.AdjustFollowCounts(ctx, database.AdjustFollowCountsParams{
+		FolloweeID: followeeID,
+		Delta:      -1,
+		FollowerID: followerID,
+	})
+	if err != nil {
+		return err
+	}
+	return qtx.DeleteTimelineEntriesByAuthor(ctx, database.DeleteTimelineEntriesByAuthorParams{
+		UserID:   followerID,
+		AuthorID: followeeID,
+	})
+}
+
+// Same shape as a chirp cursor, keyed on when the follow happened
+func encodeFollowCursor(follow Follow) string {
+	data, _ := json.Marshal(chirpCursor{CreatedAt: follow.FollowedAt, ID: follow.UserID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (cfg *apiConfig) listFollowers(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, "followers", func(ctx context.Context, params database.ListFollowersParams) ([]Follow, error) {
+		rows, err := cfg.dbQuerries.ListFollowers(ctx, params)
+		follows := []Follow{}
+		for _, row := range rows {
+			follows = append(follows, Follow{UserID: row.UserID, FollowedAt: row.CreatedAt})
+		}
+		return follows, err
+	})
+}
+
+func (cfg *apiConfig) listFollowing(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, "following", func(ctx context.Context, params database.ListFollowersParams) ([]Follow, error) {
+		rows, err := cfg.dbQuerries.ListFollowing(ctx, database.ListFollowingParams(params))
+		follows := []Follow{}
+		for _, row := range rows {
+			follows = append(follows, Follow{UserID: row.UserID, FollowedAt: row.CreatedAt})
+		}
+		return follows, err
+	})
+}
+
+// A page of a user's followers or of who they follow, most recent first,
+// with the total count
+func (cfg *apiConfig) listFollows(w http.ResponseWriter, r *http.Request, list string, fetch func(context.Context, database.ListFollowersParams) ([]Follow, error)) {
+	type response struct {
+		Count int32    `json:"count"`
+		Users []Follow `json:"users"`
+	}
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad user ID")
+		return
+	}
+	query := r.URL.Query()
+	limit, err := pageSize(query)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	params := database.ListFollowersParams{
+		UserID:     userID,
+		MaxResults: limit + 1,
+	}
+	if cursorParam := query.Get("cursor"); cursorParam != "" {
+		cursor, err := decodeChirpCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, 400, err.Error())
+			return
+		}
+		params.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	ctx := context.Background()
+	user, err := cfg.dbQuerries.GetUserById(ctx, userID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: loading user %v: %v", userID, err)
+		respondWithError(w, 500, "Unable to get "+list)
+		return
+	}
+	follows, err := fetch(ctx, params)
+	if err != nil {
+		log.Printf("ERROR: listing %v of %v: %v", list, userID, err)
+		respondWithError(w, 500, "Unable to get "+list)
+		return
+	}
+	if len(follows) > int(limit) {
+		follows = follows[:limit]
+		setNextPage(w, r, encodeFollowCursor(follows[len(follows)-1]))
+	}
+
+	resp := response{Count: user.FollowerCount, Users: follows}
+	if list == "following" {
+		resp.Count = user.FollowingCount
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+// The caller's home timeline, newest first. Pages like GET /api/chirps.
+func (cfg *apiConfig) getTimeline(w http.ResponseWriter, r *http.Request) {
+	userID := requestPrincipal(r).UserID
+	query := r.URL.Query()
+	limit, err := pageSize(query)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	params := database.ListTimelineParams{
+		UserID:     userID,
+		MaxResults: limit + 1,
+	}
+	if cursorParam := query.Get("cursor"); cursorParam != "" {
+		cursor, err := decodeChirpCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, 400, err.Error())
+			return
+		}
+		params.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	ctx := context.Background()
+	chirps, err := cfg.dbQuerries.ListTimeline(ctx, params)
+	if err != nil {
+		log.Printf("ERROR: loading timeline for %v: %v", userID, err)
+		respondWithError(w, 500, "Unable to get timeline")
+		return
+	}
+	if len(chirps) > int(limit) {
+		chirps = chirps[:limit]
+		setNextPage(w, r, encodeChirpCursor(chirps[len(chirps)-1]))
+	}
+
+	response := []Chirp{}
+	for _, chirp := range chirps {
+		response = append(response, chirpResponse(chirp))
+	}
+	embedded := []*Chirp{}
+	for i := range response {
+		embedded = append(embedded, &response[i])
+	}
+	err = cfg.prepareChirps(ctx, r, embedded)
+	if err != nil {
+		log.Printf("ERROR: loading rechirped chirps for %v: %v", userID, err)
+		respondWithError(w, 500, "Unable to get timeline")
+		return
+	}
+	respondWithJSON(w, 200, response)
+}
@@ -10,4 +10,9 @@ require (
 
 require github.com/lib/pq v1.10.9
 
-require github.com/golang-jwt/jwt/v5 v5.2.2 // indirect
+require (
+	github.com/golang-jwt/jwt/v5 v5.2.2
+	golang.org/x/image v0.25.0
+)
+
+require golang.org/x/sys v0.31.0 // indirect
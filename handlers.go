@@ -2,19 +2,25 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/mail"
 	"os"
-	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	auth "github.com/avgra3/chirpy/internal/auth"
 	"github.com/avgra3/chirpy/internal/database"
+	"github.com/avgra3/chirpy/internal/entitlements"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // Handlers
@@ -43,19 +49,77 @@ func (cfg *apiConfig) userLogin(respWriter http.ResponseWriter, req *http.Reques
 	// }
 
 	ctx := context.Background()
+	// Refuse throttled callers before spending any time on bcrypt
+	ip := clientIP(req)
+	retryAfter, err := cfg.ipRetryAfter(ctx, ip)
+	if err != nil {
+		respondWithError(respWriter, 500, "Unable to log in")
+		return
+	}
+	if retryAfter > 0 {
+		respondWithRetryAfter(respWriter, retryAfter)
+		return
+	}
 	user, err := cfg.dbQuerries.UserLogin(ctx, userByEmail.Email)
 	if err != nil {
+		cfg.recordFailedLogin(ctx, ip, uuid.Nil)
 		respondWithError(respWriter, 401, "Incorrect email or password")
 		return
 	}
-	err = auth.CheckPasswordHash(user.HashedPassword, userByEmail.Password)
+	retryAfter, err = cfg.accountRetryAfter(ctx, user.ID)
+	if err != nil {
+		respondWithError(respWriter, 500, "Unable to log in")
+		return
+	}
+	if retryAfter > 0 {
+		respondWithRetryAfter(respWriter, retryAfter)
+		return
+	}
+	needsRehash, err := cfg.passwordHasher.Verify(user.HashedPassword, userByEmail.Password)
 	if err != nil {
+		cfg.recordFailedLogin(ctx, ip, user.ID)
 		respondWithError(respWriter, 401, "Incorrect email or password")
 		return
 	}
+	// We only ever see the plain password here, so this is our one chance to
+	// move it onto the current algorithm and parameters
+	if needsRehash {
+		cfg.rehashPassword(ctx, user.ID, userByEmail.Password)
+	}
+	// With 2FA on, the password only gets you as far as the second step
+	if user.TotpEnabledAt.Valid {
+		cfg.respondWithLoginChallenge(respWriter, user)
+		return
+	}
+	cfg.completeLogin(respWriter, req, user)
+}
+
+func (cfg *apiConfig) rehashPassword(ctx context.Context, userID uuid.UUID, password string) {
+	hashedPassword, err := cfg.passwordHasher.Hash(password)
+	if err == nil {
+		err = cfg.dbQuerries.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+			HashedPassword: hashedPassword,
+			ID:             userID,
+		})
+	}
+	if err != nil {
+		log.Printf("ERROR: rehashing password for %v: %v", userID, err)
+	}
+}
+
+// Issues the access and refresh tokens for a user who has proven who they are
+func (cfg *apiConfig) completeLogin(respWriter http.ResponseWriter, req *http.Request, user database.User) {
+	// Failures only reset once every factor has passed
+	if user.FailedLoginAttempts > 0 || user.LockedUntil.Valid {
+		_, err := cfg.dbQuerries.ResetFailedLogins(context.Background(), user.ID)
+		if err != nil {
+			log.Printf("ERROR: resetting failed logins for %v: %v", user.ID, err)
+		}
+	}
+
 	// Once we are sure the user can log in, we create the JWT
 	jwtDuration := time.Duration(60*60) * time.Second
-	jwt, err := auth.MakeJWT(user.ID, cfg.jwtSecret, jwtDuration)
+	jwt, err := cfg.keyRing.MakeJWT(auth.NewPrincipal(user.ID, user.Role), jwtDuration)
 	if err != nil {
 		respondWithError(respWriter, 500, "Unable to create token at this time")
 		return
@@ -64,18 +128,25 @@ func (cfg *apiConfig) userLogin(respWriter http.ResponseWriter, req *http.Reques
 	refreshToken, _ := auth.MakeRefreshToken()
 
 	authUser := User{
-		ID:           user.ID,
-		CreatedAt:    user.CreatedAt,
-		UpdatedAt:    user.UpdatedAt,
-		Email:        user.Email,
-		Token:        jwt,
-		RefreshToken: refreshToken,
-		IsChirpyRed:  user.IsChirpyRed.Bool,
+		ID:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		Token:         jwt,
+		RefreshToken:  refreshToken,
+		IsChirpyRed:   user.IsChirpyRed.Bool,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		PendingEmail:  user.PendingEmail.String,
 	}
 
 	// Makes new Refresh token in the database
-	ctx = context.Background()
-	_, err = cfg.dbQuerries.NewRereshToken(ctx, database.NewRereshTokenParams{Token: refreshToken, UserID: authUser.ID})
+	ctx := context.Background()
+	_, err = cfg.dbQuerries.NewRereshToken(ctx, database.NewRereshTokenParams{
+		Token:     refreshToken,
+		UserID:    authUser.ID,
+		UserAgent: req.UserAgent(),
+		IpAddress: clientIP(req),
+	})
 	if err != nil {
 		respondWithError(respWriter, 401, "Unable to refresh token")
 		return
@@ -107,33 +178,104 @@ func (cfg *apiConfig) refreshToken(respWriter http.ResponseWriter, req *http.Req
 	}
 	// If the token has a revoked at date (meaning now invalid)
 	if userByToken.RevokedAt.Valid {
+		// A token that was retired by rotation should never come back. If it
+		// does, someone else has a copy of it, so kill the whole family.
+		if userByToken.ReplacedBy.Valid {
+			cfg.revokeRefreshTokenFamily(ctx, userByToken)
+		}
+		respondWithError(respWriter, 401, "Refresh token revoked")
+		return
+	}
+	if time.Now().After(userByToken.ExpiresAt) {
+		respondWithError(respWriter, 401, "Refresh token expired")
+		return
+	}
+
+	// Every use of a refresh token retires it and hands out its replacement
+	newRefreshToken, err := cfg.rotateRefreshToken(ctx, userByToken, req)
+	if err == errRefreshTokenReused {
+		cfg.revokeRefreshTokenFamily(ctx, userByToken)
 		respondWithError(respWriter, 401, "Refresh token revoked")
 		return
 	}
-	// Make new token
+	if err != nil {
+		log.Printf("ERROR: rotating refresh token: %v", err)
+		respondWithError(respWriter, 500, "Unable to refresh token")
+		return
+	}
+
+	// Make new token, picking up any role change since the last one
+	user, err := cfg.dbQuerries.GetUserById(ctx, userByToken.UserID)
+	if err != nil {
+		respondWithError(respWriter, 401, "Does not exist")
+		return
+	}
 	jwtDuration, _ := time.ParseDuration("1h")
-	newToken, err := auth.MakeJWT(userByToken.UserID, cfg.jwtSecret, jwtDuration)
+	newToken, err := cfg.keyRing.MakeJWT(auth.NewPrincipal(user.ID, user.Role), jwtDuration)
+	if err != nil {
+		respondWithError(respWriter, 500, "Unable to create token at this time")
+		return
+	}
 
 	// We are good to go!
 	type responseValue struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
-	respondWithJSON(respWriter, 200, responseValue{Token: newToken})
+	respondWithJSON(respWriter, 200, responseValue{Token: newToken, RefreshToken: newRefreshToken})
 }
 
-func (cfg *apiConfig) updateEmailPassword(respWriter http.ResponseWriter, req *http.Request) {
-	// Expect header to contain an access token
-	accessToken, err := auth.GetBearerToken(req.Header)
+var errRefreshTokenReused = errors.New("refresh token was already used")
+
+// Retires the given refresh token and issues a new one in the same family.
+// The new token records the device details of the request that used it.
+// Returns errRefreshTokenReused if another request rotated the token first.
+func (cfg *apiConfig) rotateRefreshToken(ctx context.Context, current database.RefreshToken, req *http.Request) (string, error) {
+	newRefreshToken, err := auth.MakeRefreshToken()
 	if err != nil {
-		respondWithError(respWriter, 401, "Bad access token")
-		return
+		return "", err
 	}
-	// Validate accessToken
-	userID, err := auth.ValidateJWT(accessToken, cfg.jwtSecret)
+	tx, err := cfg.db.BeginTx(ctx, nil)
 	if err != nil {
-		respondWithError(respWriter, 401, "Bad access token")
-		return
+		return "", err
 	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	rotated, err := qtx.RotateRefreshToken(ctx, database.RotateRefreshTokenParams{
+		Token:      current.Token,
+		ReplacedBy: sql.NullString{String: newRefreshToken, Valid: true},
+	})
+	if err != nil {
+		return "", err
+	}
+	if rotated == 0 {
+		return "", errRefreshTokenReused
+	}
+	_, err = qtx.NewRefreshTokenInFamily(ctx, database.NewRefreshTokenInFamilyParams{
+		Token:     newRefreshToken,
+		UserID:    current.UserID,
+		FamilyID:  current.FamilyID,
+		UserAgent: req.UserAgent(),
+		IpAddress: clientIP(req),
+	})
+	if err != nil {
+		return "", err
+	}
+	return newRefreshToken, tx.Commit()
+}
+
+func (cfg *apiConfig) revokeRefreshTokenFamily(ctx context.Context, token database.RefreshToken) {
+	log.Printf("WARNING: refresh token reuse for user %v, revoking family %v", token.UserID, token.FamilyID)
+	err := cfg.dbQuerries.RevokeRefreshTokenFamily(ctx, token.FamilyID)
+	if err != nil {
+		log.Printf("ERROR: revoking refresh token family %v: %v", token.FamilyID, err)
+	}
+}
+
+func (cfg *apiConfig) updateEmailPassword(respWriter http.ResponseWriter, req *http.Request) {
+	// requireAuth has already checked the access token
+	userID := requestPrincipal(req).UserID
 
 	type emailPassRequestBody struct {
 		Password string `json:"password"`
@@ -151,27 +293,55 @@ func (cfg *apiConfig) updateEmailPassword(respWriter http.ResponseWriter, req *h
 		respondWithError(respWriter, 500, "couldn't unmarshal request body")
 		return
 	}
-	// Need to hash the password
-	hashedPassword, err := auth.HashPassword(params.Password)
-	if err != nil {
-		respondWithError(respWriter, 500, "couldn't has password")
-		return
-	}
-	// Need to update the users table with new email and password
-	updates := database.UpdateUserEmailPasswordParams{
-		Email:          params.Email,
-		HashedPassword: hashedPassword,
-		ID:             userID,
-	}
-	// Make the update
 	ctx := context.Background()
-	updatedUser, err := cfg.dbQuerries.UpdateUserEmailPassword(ctx, updates)
+	user, err := cfg.dbQuerries.GetUserById(ctx, userID)
 	if err != nil {
 		respondWithError(respWriter, 500, "Unable to complete request")
 		return
 	}
+	// Checked before anything is written, so a bad request changes nothing
+	changeEmail := params.Email != "" && params.Email != user.Email
+	if changeEmail && !validEmail(params.Email) {
+		respondWithError(respWriter, 400, "entered email was invalid")
+		return
+	}
+	if params.Password != "" {
+		// Need to hash the password
+		hashedPassword, err := cfg.passwordHasher.Hash(params.Password)
+		if err != nil {
+			respondWithError(respWriter, 500, "couldn't has password")
+			return
+		}
+		err = cfg.dbQuerries.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+			HashedPassword: hashedPassword,
+			ID:             userID,
+		})
+		if err != nil {
+			respondWithError(respWriter, 500, "Unable to complete request")
+			return
+		}
+	}
+
+	// A new email only replaces the current one once it has been confirmed
+	if changeEmail {
+		err = cfg.dbQuerries.SetUserPendingEmail(ctx, database.SetUserPendingEmailParams{
+			ID:           userID,
+			PendingEmail: sql.NullString{String: params.Email, Valid: true},
+		})
+		if err != nil {
+			respondWithError(respWriter, 500, "Unable to complete request")
+			return
+		}
+		err = cfg.sendEmailVerification(ctx, userID, params.Email)
+		if err != nil {
+			log.Printf("ERROR: sending email verification: %v", err)
+			respondWithError(respWriter, 500, "Unable to send verification email")
+			return
+		}
+		user.PendingEmail = sql.NullString{String: params.Email, Valid: true}
+	}
 
-	respondWithJSON(respWriter, 200, updatedUser)
+	respondWithJSON(respWriter, 200, userResponse(user))
 
 }
 
@@ -217,13 +387,17 @@ func (cfg *apiConfig) newUserHandler(respWriter http.ResponseWriter, req *http.R
 		respondWithError(respWriter, 400, "entered email was invalid (empty string)")
 		return
 	}
+	if !validEmail(params.Email) {
+		respondWithError(respWriter, 400, "entered email was invalid")
+		return
+	}
 	if strings.Trim(params.Password, " ") == "" {
 		respondWithError(respWriter, 400, "entered password was invalid (empty string)")
 		return
 	}
 
 	// Need to actually make the user:
-	hashedPassword, err := auth.HashPassword(params.Password)
+	hashedPassword, err := cfg.passwordHasher.Hash(params.Password)
 	if err != nil {
 		respondWithError(respWriter, 500, "unable to hash password")
 		return
@@ -239,7 +413,12 @@ func (cfg *apiConfig) newUserHandler(respWriter http.ResponseWriter, req *http.R
 		respondWithError(respWriter, 500, message)
 		return
 	}
-	hashedPasword, err := auth.HashPassword(newUser.HashedPassword)
+	// The account can't post until the email is confirmed
+	err = cfg.sendEmailVerification(ctx, newUser.ID, newUser.Email)
+	if err != nil {
+		log.Printf("ERROR: sending email verification: %v", err)
+	}
+	hashedPasword, err := cfg.passwordHasher.Hash(newUser.HashedPassword)
 	if err != nil {
 		message := fmt.Sprintf("Error hashing password")
 		respondWithError(respWriter, 500, message)
@@ -255,6 +434,7 @@ func (cfg *apiConfig) newUserHandler(respWriter http.ResponseWriter, req *http.R
 		Email:          newUser.Email,
 		HashedPassword: hashedPasword,
 		IsChirpyRed:    newUser.IsChirpyRed.Bool,
+		EmailVerified:  newUser.EmailVerifiedAt.Valid,
 	}
 	respondWithJSON(respWriter, 201, ourUser)
 	return
@@ -270,6 +450,13 @@ func readiness(respWriter http.ResponseWriter, req *http.Request) {
 	respondWithText(respWriter, 200, []byte("OK"))
 }
 
+// Publishes the public half of every signing key so other services can
+// verify our access tokens without sharing a secret
+func (cfg *apiConfig) jwks(respWriter http.ResponseWriter, req *http.Request) {
+	respWriter.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(respWriter, 200, cfg.keyRing.JWKS())
+}
+
 // Want a handler that writes the number of requests that have been counted as plain text in this format to the HTTP response:
 // Hits: x
 func (cfg *apiConfig) hitCounter(respWriter http.ResponseWriter, req *http.Request) {
@@ -314,48 +501,83 @@ func (cfg *apiConfig) adminHandler(respWriter http.ResponseWriter, req *http.Req
 
 // Handler to encode JSON response
 func (cfg *apiConfig) getChirps(w http.ResponseWriter, r *http.Request) {
-	// See if the user wants to filter by user id
-	authorIDParam := r.URL.Query().Get("author_id")
-	sortParam := r.URL.Query().Get("sort")
-
-	ctx := context.Background()
+	query := r.URL.Query()
+	sortParam := query.Get("sort")
+	if sortParam != "" && sortParam != "asc" && sortParam != "desc" {
+		respondWithError(w, 400, "sort must be asc or desc")
+		return
+	}
+	limit, err := pageSize(query)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	authorIDs, err := authorIDsParam(query)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	since, err := timeParam(query, "since")
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	until, err := timeParam(query, "until")
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
 
-	if authorIDParam == "" {
-		chirps, err := cfg.dbQuerries.GetChirps(ctx)
+	// One extra row tells us whether there's another page
+	params := database.ListChirpsOldestFirstParams{
+		AuthorIds:  authorIDs,
+		Since:      since,
+		Until:      until,
+		ViewerID:   requestPrincipal(r).UserID,
+		MaxResults: limit + 1,
+	}
+	if cursorParam := query.Get("cursor"); cursorParam != "" {
+		cursor, err := decodeChirpCursor(cursorParam)
 		if err != nil {
-			errMessage := fmt.Sprintf("ERROR: %v", err)
-			respondWithError(w, 500, errMessage)
-			return
-		}
-		if sortParam == "" || sortParam == "asc" {
-			respondWithJSON(w, 200, chirps)
-			return
-		}
-		if sortParam == "desc" {
-			sort.Slice(chirps, func(i, j int) bool { return chirps[i].CreatedAt.After(chirps[j].CreatedAt) })
-			respondWithJSON(w, 200, chirps)
+			respondWithError(w, 400, err.Error())
 			return
 		}
-	}
-	authorUUID, err := uuid.Parse(authorIDParam)
-	if err != nil {
-		respondWithError(w, 500, "Unable to parse author_id")
-		return
+		params.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
 	}
 
-	chirps, err := cfg.dbQuerries.GetChirpsByAuthorID(ctx, authorUUID)
+	ctx := context.Background()
+	var chirps []database.Chirp
+	if sortParam == "desc" {
+		chirps, err = cfg.dbQuerries.ListChirpsNewestFirst(ctx, database.ListChirpsNewestFirstParams(params))
+	} else {
+		chirps, err = cfg.dbQuerries.ListChirpsOldestFirst(ctx, params)
+	}
 	if err != nil {
+		log.Printf("ERROR: listing chirps: %v", err)
 		respondWithError(w, 500, "There was a problem trying to get chirps.")
 		return
 	}
-	if sortParam == "asc" || sortParam == "" {
-		respondWithJSON(w, 200, chirps)
-		return
+	if len(chirps) > int(limit) {
+		chirps = chirps[:limit]
+		setNextPage(w, r, encodeChirpCursor(chirps[len(chirps)-1]))
 	}
-	if sortParam == "desc" {
-		sort.Slice(chirps, func(i, j int) bool { return chirps[i].CreatedAt.After(chirps[j].CreatedAt) })
+
+	response := []Chirp{}
+	for _, chirp := range chirps {
+		response = append(response, chirpResponse(chirp))
+	}
+	embedded := []*Chirp{}
+	for i := range response {
+		embedded = append(embedded, &response[i])
+	}
+	err = cfg.prepareChirps(ctx, r, embedded)
+	if err != nil {
+		log.Printf("ERROR: loading rechirped chirps: %v", err)
+		respondWithError(w, 500, "There was a problem trying to get chirps.")
 		return
 	}
+	respondWithJSON(w, 200, response)
 }
 
 func (cfg *apiConfig) getChirp(w http.ResponseWriter, r *http.Request) {
@@ -368,33 +590,34 @@ func (cfg *apiConfig) getChirp(w http.ResponseWriter, r *http.Request) {
 	chirpID, err := uuid.Parse(chirpIDStr)
 	if err != nil {
 		errMessage := fmt.Sprintf("ERROR: %v", err)
-		respondWithError(w, 500, errMessage)
-
+		respondWithError(w, 400, errMessage)
+		return
 	}
-	chirps, err := cfg.dbQuerries.GetChirpByChirpID(ctx, chirpID)
+	chirp, err := cfg.dbQuerries.GetChirpByChirpID(ctx, chirpID)
 	if err != nil {
 		errMessage := fmt.Sprintf("ERROR: %v", err)
 		respondWithError(w, 404, errMessage)
+		return
 	}
 
-	respondWithJSON(w, 200, chirps)
-	return
-}
-
-func (cfg *apiConfig) deleteChirp(w http.ResponseWriter, r *http.Request) {
-	// Check token in header
-	log.Printf("HEADER: %v", r.Header)
-	accessToken, err := auth.GetBearerToken(r.Header)
+	resp := chirpResponse(chirp)
+	err = cfg.prepareChirps(ctx, r, []*Chirp{&resp})
 	if err != nil {
-		respondWithError(w, 401, "Bad access token")
+		log.Printf("ERROR: loading original of %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to get chirp")
 		return
 	}
-	// The user ID is giving the chirp ID
-	userID, err := auth.ValidateJWT(accessToken, cfg.jwtSecret)
-	if err != nil {
-		respondWithError(w, 401, "Bad access token")
+	if resp.Hidden {
+		respondWithError(w, 404, "Chirp not found")
 		return
 	}
+	respondWithJSON(w, 200, resp)
+	return
+}
+
+func (cfg *apiConfig) deleteChirp(w http.ResponseWriter, r *http.Request) {
+	// The user ID is giving the chirp ID
+	userID := requestPrincipal(r).UserID
 	// Chirp ID to delete
 	chirpIDStr := r.PathValue("chirpID")
 	if chirpIDStr == "" {
@@ -403,109 +626,72 @@ func (cfg *apiConfig) deleteChirp(w http.ResponseWriter, r *http.Request) {
 	}
 	chirpID, err := uuid.Parse(chirpIDStr)
 	if err != nil {
-		respondWithError(w, 401, "Bad chirp ID")
+		respondWithError(w, 400, "Bad chirp ID")
 		return
 	}
 
-	// Only allow deletion if the user is the owner of the chirp
 	ctx := context.Background()
-	deleteChirpParams := database.DeleteChirpParams{ID: chirpID, UserID: userID}
-	affectedRows, err := cfg.dbQuerries.DeleteChirp(ctx, deleteChirpParams)
+	tx, err := cfg.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("ERROR: %v", err)
 		respondWithError(w, 500, "Server error!")
 		return
 	}
-	// Check no rows affected
-	if affectedRows == 0 {
-		// Try to get the chirp, regardless of owner
-		chirp, err := cfg.dbQuerries.GetChirp(ctx, userID)
-		if err != nil || chirp == (database.Chirp{}) {
-			respondWithError(w, 403, "Chirp not found")
-		} else if chirp.UserID != userID {
-			respondWithError(w, 403, "You don't own this chirp!")
-
-		} else {
-			// log.Printf("ERROR: chirp.ID => %v", chirp.ID)
-			// log.Printf("ERROR: chirp.UserID => %v", chirp.UserID)
-			// log.Printf("ERROR: chirp id we have => %v", chirpID)
-			// log.Printf("ERROR: user id we have => %v", userID)
-			// log.Printf("ERROR: chirp.ID == chirpID: %v", chirp.ID == chirpID)
-			// log.Printf("ERROR: chirp.UserID == userID: %v", chirp.UserID == userID)
-			// log.Printf("ERROR: len(chirp.ID) = %v", len(chirp.UserID))
-			// log.Printf("ERROR: len(userID) = %v", len(userID))
-			// log.Printf("ERROR: chirpIDStr value = %v", chirpIDStr)
-			// log.Printf("ERROR: Requested Path: %s", r.URL.Path)
-			// log.Printf("ERROR: Requested Raw Path: %s", r.URL.RawPath)
-			//
-			// log.Printf("ERROR: %v", err)
-			respondWithError(w, 403, "We dont know what the error is!")
-		}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	// Locked, so nobody can reply while we decide how to delete it
+	chirp, err := qtx.GetChirpForDelete(ctx, chirpID)
+	if err == sql.ErrNoRows || (err == nil && chirp.DeletedAt.Valid) {
+		respondWithError(w, 404, "Chirp not found")
 		return
 	}
-
-	message := fmt.Sprintf("Successfully (hopefully) deleted CHIRP ID: %v \n", chirpID)
-	log.Println(message)
-
-	// We successfully deleted!
-	w.WriteHeader(204)
-}
-
-func (cfg *apiConfig) upgradeUserToRed(w http.ResponseWriter, r *http.Request) {
-	_, err := auth.GetAPIKey(r.Header)
 	if err != nil {
-		w.WriteHeader(401)
+		log.Printf("ERROR: %v", err)
+		respondWithError(w, 500, "Server error!")
 		return
 	}
-
-	type parameters struct {
-		Event string `json:"event"`
-		Data  struct {
-			UserID uuid.UUID `json:"user_id"`
-		} `json:"data"`
+	// Only allow deletion if the user is the owner of the chirp
+	if chirp.UserID != userID {
+		respondWithError(w, 403, "You don't own this chirp!")
+		return
 	}
-	defer r.Body.Close()
 
-	data, err := io.ReadAll(r.Body)
+	// Rechirps and quotes come off their original's counts
+	err = releaseOriginal(ctx, qtx, chirp)
 	if err != nil {
-		respondWithError(w, 404, "Unable to read body")
+		log.Printf("ERROR: uncounting chirp %v: %v", chirpID, err)
+		respondWithError(w, 500, "Server error!")
 		return
 	}
-	params := parameters{}
-	err = json.Unmarshal(data, &params)
-	if err != nil {
-		respondWithError(w, 500, "couldn't unmarshal parameters")
-		return
+	if chirp.ReplyCount > 0 {
+		err = tombstoneChirp(ctx, qtx, chirp.ID)
+	} else {
+		err = deleteChirpAndTombstones(ctx, qtx, chirp.ID, chirp.UserID, chirp.InReplyTo)
 	}
-	if params.Event != "user.upgraded" {
-		w.WriteHeader(204)
+	if err != nil {
+		log.Printf("ERROR: deleting chirp %v: %v", chirpID, err)
+		respondWithError(w, 500, "Server error!")
 		return
 	}
-	// Need to update user in the database
-	ctx := context.Background()
-	// Double check user exists
-	userCheck, err := cfg.dbQuerries.GetUserById(ctx, params.Data.UserID)
-	if userCheck.ID != params.Data.UserID {
-		respondWithError(w, 404, "User does not exist")
-	}
-
-	err = cfg.dbQuerries.UpgradeUserToChirpyRed(ctx, params.Data.UserID)
+	err = tx.Commit()
 	if err != nil {
-		respondWithError(w, 500, "Unable to complete upgrade")
+		respondWithError(w, 500, "Server error!")
 		return
 	}
-	// Should have been successful, return 204
-	w.WriteHeader(204)
-	return
+	log.Printf("Deleted chirp %v", chirpID)
 
+	// We successfully deleted!
+	w.WriteHeader(204)
 }
 
 func (cfg *apiConfig) newChirps(w http.ResponseWriter, r *http.Request) {
 	// Request parameters
 	type parameters struct {
-		Body   string    `json:"body"`
-		UserId uuid.UUID `json:"user_id"`
-		Token  string    `json:"token"`
+		Body      string     `json:"body"`
+		UserId    uuid.UUID  `json:"user_id"`
+		Token     string     `json:"token"`
+		InReplyTo *uuid.UUID `json:"in_reply_to"`
+		QuoteOf   *uuid.UUID `json:"quote_of"`
 	}
 
 	defer r.Body.Close()
@@ -520,46 +706,134 @@ func (cfg *apiConfig) newChirps(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, 500, "couldn't unmarshal parameters")
 		return
 	}
-	if len(params.Body) > 120 {
-		errorMessage := "Chirp is too long"
-		respondWithError(w, 400, errorMessage)
+	// requireAuth has already checked the access token
+	userID := requestPrincipal(r).UserID
+	ctx := context.Background()
+	author, err := cfg.dbQuerries.GetUserById(ctx, userID)
+	if err != nil {
+		respondWithError(w, 401, "User does not exist")
 		return
 	}
-	// Check valid token
-	refreshToken, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		errorMessage := fmt.Sprintf("Error: %v", err)
-		respondWithError(w, 401, errorMessage)
+	if !author.EmailVerifiedAt.Valid {
+		respondWithError(w, 403, "Verify your email before posting chirps")
+		return
+	}
+	// The limit depends on the author's tier
+	if !cfg.userMay(author, entitlements.ChirpLength, int64(utf8.RuneCountInString(params.Body))) {
+		errorMessage := fmt.Sprintf("Chirp is too long (limit is %v characters)", cfg.userLimit(author, entitlements.ChirpLength))
+		respondWithError(w, 400, errorMessage)
 		return
 	}
-	// The token we have is a refresh token -- we need to get the access token
-	// We have the userID, so we need to get the access token
-	userID, err := auth.ValidateJWT(refreshToken, cfg.jwtSecret)
+
+	tx, err := cfg.db.BeginTx(ctx, nil)
 	if err != nil {
-		errorMessage := fmt.Sprintf("TOKEN: %v", refreshToken)
-		respondWithError(w, 401, errorMessage)
+		respondWithError(w, 500, "Unable to post chirp")
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
 
 	validChirp := database.PostChirpParams{
 		Body:   cleanWords(params.Body),
 		UserID: userID,
 	}
-	ctx := context.Background()
-	newChirp, err := cfg.dbQuerries.PostChirp(ctx, validChirp)
+	if params.InReplyTo != nil {
+		// Chirps from either side of a block can't be replied to, as if
+		// they weren't there
+		blocked, err := isBlockedFromChirp(ctx, qtx, userID, *params.InReplyTo)
+		if err != nil {
+			log.Printf("ERROR: checking blocks on %v: %v", *params.InReplyTo, err)
+			respondWithError(w, 500, "Unable to post chirp")
+			return
+		}
+		if blocked {
+			respondWithError(w, 404, "The chirp you're replying to doesn't exist")
+			return
+		}
+		// Also locks the parent, so it can't be deleted out from under the reply
+		updated, err := qtx.IncrementReplyCount(ctx, *params.InReplyTo)
+		if err != nil {
+			log.Printf("ERROR: counting reply to %v: %v", *params.InReplyTo, err)
+			respondWithError(w, 500, "Unable to post chirp")
+			return
+		}
+		if updated == 0 {
+			respondWithError(w, 404, "The chirp you're replying to doesn't exist")
+			return
+		}
+		validChirp.InReplyTo = uuid.NullUUID{UUID: *params.InReplyTo, Valid: true}
+	}
+	if params.QuoteOf != nil {
+		if strings.TrimSpace(params.Body) == "" {
+			respondWithError(w, 400, "A quote chirp needs a body; rechirp it instead")
+			return
+		}
+		// Quoting a rechirp quotes what it points at
+		quoted, err := originalChirpID(ctx, qtx, *params.QuoteOf)
+		if err == nil {
+			var blocked bool
+			blocked, err = isBlockedFromChirp(ctx, qtx, userID, quoted)
+			if err == nil && blocked {
+				err = sql.ErrNoRows
+			}
+		}
+		if err == nil {
+			var updated int64
+			updated, err = qtx.IncrementQuoteCount(ctx, quoted)
+			if err == nil && updated == 0 {
+				err = sql.ErrNoRows
+			}
+		}
+		if err == sql.ErrNoRows {
+			respondWithError(w, 404, "The chirp you're quoting doesn't exist")
+			return
+		}
+		if err != nil {
+			log.Printf("ERROR: counting quote of %v: %v", *params.QuoteOf, err)
+			respondWithError(w, 500, "Unable to post chirp")
+			return
+		}
+		validChirp.QuoteOf = uuid.NullUUID{UUID: quoted, Valid: true}
+	}
+	newChirp, err := qtx.PostChirp(ctx, validChirp)
 	if err != nil {
 		errMessage := fmt.Sprintf("ERROR: %v", err)
 		respondWithError(w, 500, errMessage)
+		return
+	}
+	err = tagChirp(ctx, qtx, newChirp)
+	if err != nil {
+		log.Printf("ERROR: tagging chirp %v: %v", newChirp.ID, err)
+		respondWithError(w, 500, "Unable to post chirp")
+		return
 	}
-	actualChirp := Chirp{
-		ID:        newChirp.ID,
-		CreatedAt: newChirp.CreatedAt,
-		UpdatedAt: newChirp.UpdatedAt,
-		Body:      newChirp.Body,
-		UserID:    newChirp.UserID,
+	err = notifyMentions(ctx, qtx, newChirp)
+	if err == nil && newChirp.InReplyTo.Valid {
+		err = qtx.NotifyReply(ctx, newChirp.ID)
 	}
-
-	respondWithJSON(w, 201, actualChirp)
+	if err != nil {
+		log.Printf("ERROR: notifying about chirp %v: %v", newChirp.ID, err)
+		respondWithError(w, 500, "Unable to post chirp")
+		return
+	}
+	err = cfg.addToTimelines(ctx, qtx, newChirp, author)
+	if err != nil {
+		log.Printf("ERROR: queueing chirp %v for timelines: %v", newChirp.ID, err)
+		respondWithError(w, 500, "Unable to post chirp")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Unable to post chirp")
+		return
+	}
+	cfg.wakeTimelineFanout()
+	resp := chirpResponse(newChirp)
+	err = cfg.prepareChirps(ctx, r, []*Chirp{&resp})
+	if err != nil {
+		log.Printf("ERROR: loading original of %v: %v", newChirp.ID, err)
+	}
+	respondWithJSON(w, 201, resp)
 	return
 }
 
@@ -621,6 +895,88 @@ func cleanWords(input string) string {
 	return strings.Join(cleanedInput, " ")
 }
 
+// The public view of a user; never includes the password hash
+func userResponse(user database.User) User {
+	return User{
+		ID:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		IsChirpyRed:   user.IsChirpyRed.Bool,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		PendingEmail:  user.PendingEmail.String,
+	}
+}
+
+func chirpResponse(chirp database.Chirp) Chirp {
+	return Chirp{
+		ID:           chirp.ID,
+		CreatedAt:    chirp.CreatedAt,
+		UpdatedAt:    chirp.UpdatedAt,
+		Body:         chirp.Body,
+		UserID:       chirp.UserID,
+		InReplyTo:    nullUUID(chirp.InReplyTo),
+		ReplyCount:   chirp.ReplyCount,
+		RechirpOf:    nullUUID(chirp.RechirpOf),
+		QuoteOf:      nullUUID(chirp.QuoteOf),
+		RechirpCount: chirp.RechirpCount,
+		QuoteCount:   chirp.QuoteCount,
+		LikeCount:    chirp.LikeCount,
+		Deleted:      chirp.DeletedAt.Valid,
+	}
+}
+
+// Fills in what chirpResponse can't: the chirps that rechirps and quotes
+// point at and, for signed-in callers, liked_by_me and which are hidden
+func (cfg *apiConfig) prepareChirps(ctx context.Context, r *http.Request, chirps []*Chirp) error {
+	err := cfg.embedOriginals(ctx, chirps)
+	if err != nil {
+		return err
+	}
+	viewer := requestPrincipal(r).UserID
+	if viewer == uuid.Nil {
+		return nil
+	}
+	err = cfg.hideChirps(ctx, viewer, chirps)
+	if err != nil {
+		return err
+	}
+	for _, chirp := range chirps {
+		if chirp.Original != nil {
+			chirps = append(chirps, chirp.Original)
+		}
+	}
+	return cfg.markLikedChirps(ctx, viewer, chirps)
+}
+
+func nullUUID(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
+
+// Postgres unique_violation, e.g. an email that's already taken
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// Only the address part is checked; display names aren't allowed
+func validEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
+
+// Address of the client that made the request, without the port
+func clientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
 func respondWithJSON(respWriter http.ResponseWriter, code int, payload interface{}) error {
 	response, err := json.Marshal(payload)
 	if err != nil {
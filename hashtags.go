@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Hashtags are pulled out of a chirp's body whenever it's saved and kept in
+// chirp_hashtags. Trending tags are scored by a background worker into
+// trending_hashtags, so GET /api/trending only has to read them back.
+
+const (
+	maxHashtagLength = 100
+	// How many tags the trending worker keeps
+	maxTrendingHashtags = 100
+)
+
+func isHashtagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// Tags are compared without the # and ignoring case
+func normalizeHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+// Letters, digits and underscores, with at least one letter so "#1" isn't a tag
+func validHashtag(tag string) bool {
+	if tag == "" || utf8.RuneCountInString(tag) > maxHashtagLength {
+		return false
+	}
+	hasLetter := false
+	for _, r := range tag {
+		if !isHashtagRune(r) {
+			return false
+		}
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+	return hasLetter
+}
+
+// The distinct tags in a chirp, normalized, in the order they first appear.
+// A # only starts a tag at the start of a word, so "a#b" has none.
+func extractHashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	runes := []rune(body)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' || (i > 0 && isHashtagRune(runes[i-1])) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && isHashtagRune(runes[end]) {
+			end++
+		}
+		tag := normalizeHashtag(string(runes[i+1 : end]))
+		if validHashtag(tag) && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+		i = end - 1
+	}
+	return tags
+}
+
+// Replaces a chirp's tags with the ones in its current body
+func tagChirp(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	err := qtx.UntagChirp(ctx, chirp.ID)
+	if err != nil {
+		return err
+	}
+	tags := extractHashtags(chirp.Body)
+	if len(tags) == 0 {
+		return nil
+	}
+	return qtx.TagChirp(ctx, database.TagChirpParams{
+		Tags:      tags,
+		ChirpID:   chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+	})
+}
+
+// Chirps with a tag, newest first. Pages like GET /api/chirps.
+func (cfg *apiConfig) getHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := normalizeHashtag(r.PathValue("tag"))
+	if !validHashtag(tag) {
+		respondWithError(w, 400, "Bad hashtag")
+		return
+	}
+	query := r.URL.Query()
+	limit, err := pageSize(query)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	params := database.ListHashtagChirpsParams{
+		Tag:        tag,
+		ViewerID:   requestPrincipal(r).UserID,
+		MaxResults: limit + 1,
+	}
+	if cursorParam := query.Get("cursor"); cursorParam != "" {
+		cursor, err := decodeChirpCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, 400, err.Error())
+			return
+		}
+		params.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	ctx := context.Background()
+	rows, err := cfg.dbQuerries.ListHashtagChirps(ctx, params)
+	if err != nil {
+		log.Printf("ERROR: listing chirps tagged %q: %v", tag, err)
+		respondWithError(w, 500, "There was a problem trying to get chirps.")
+		return
+	}
+	if len(rows) > int(limit) {
+		rows = rows[:limit]
+		setNextPage(w, r, encodeChirpCursor(rows[len(rows)-1].Chirp))
+	}
+
+	response := []Chirp{}
+	for _, row := range rows {
+		response = append(response, chirpResponse(row.Chirp))
+	}
+	embedded := []*Chirp{}
+	for i := range response {
+		embedded = append(embedded, &response[i])
+	}
+	err = cfg.prepareChirps(ctx, r, embedded)
+	if err != nil {
+		log.Printf("ERROR: loading quoted chirps tagged %q: %v", tag, err)
+		respondWithError(w, 500, "There was a problem trying to get chirps.")
+		return
+	}
+	respondWithJSON(w, 200, response)
+}
+
+// The tags scoring highest when the worker last ran
+func (cfg *apiConfig) getTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	limit, err := pageSize(r.URL.Query())
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	rows, err := cfg.dbQuerries.ListTrendingHashtags(context.Background(), limit)
+	if err != nil {
+		log.Printf("ERROR: listing trending hashtags: %v", err)
+		respondWithError(w, 500, "Unable to get trending hashtags")
+		return
+	}
+	trending := []TrendingHashtag{}
+	for _, row := range rows {
+		trending = append(trending, TrendingHashtag{
+			Tag:        row.Tag,
+			Score:      row.Score,
+			ChirpCount: row.ChirpCount,
+			ComputedAt: row.ComputedAt,
+		})
+	}
+	respondWithJSON(w, 200, trending)
+}
+
+// Rescores every tag used in the window. Readers see either the old
+// ranking or the new one, never a mix.
+func (cfg *apiConfig) refreshTrendingHashtags(ctx context.Context, window, halfLife time.Duration) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	err = qtx.ClearTrendingHashtags(ctx)
+	if err != nil {
+		return err
+	}
+	err = qtx.ComputeTrendingHashtags(ctx, database.ComputeTrendingHashtagsParams{
+		HalfLifeSeconds: durationSeconds(halfLife),
+		WindowSeconds:   durationSeconds(window),
+		MaxResults:      maxTrendingHashtags,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Background job started from main. Runs once straight away, then every
+// interval until the process exits.
+func (cfg *apiConfig) runTrendingHashtags(interval, window, halfLife time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := cfg.refreshTrendingHashtags(context.Background(), window, halfLife)
+		if err != nil {
+			log.Printf("ERROR: refreshing trending hashtags: %v", err)
+		}
+		<-ticker.C
+	}
+}
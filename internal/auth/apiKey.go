@@ -6,12 +6,25 @@ import (
 	"strings"
 )
 
+// Expects "Authorization: ApiKey <key>"
 func GetAPIKey(headers http.Header) (string, error) {
-	authHeader, ok := headers["Authorization"]
-	if !ok {
+	return getAuthorization(headers, "ApiKey")
+}
+
+// Splits an Authorization header into its scheme and credentials, without
+// panicking on headers that are empty or have no space in them
+func getAuthorization(headers http.Header, scheme string) (string, error) {
+	authHeader := headers.Get("Authorization")
+	if authHeader == "" {
 		return "", errors.New("Authorization header not found!")
 	}
-	apiKeyString := strings.Split(authHeader[0], " ")
-	apiKey := strings.Split(apiKeyString[1], " ")
-	return apiKey[0], nil
+	gotScheme, credentials, ok := strings.Cut(strings.TrimSpace(authHeader), " ")
+	if !ok || !strings.EqualFold(gotScheme, scheme) {
+		return "", errors.New("Authorization header is not " + scheme)
+	}
+	credentials = strings.TrimSpace(credentials)
+	if credentials == "" {
+		return "", errors.New("Authorization header is empty")
+	}
+	return credentials, nil
 }
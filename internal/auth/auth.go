@@ -1,31 +1,24 @@
 package auth
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// Hashes with the default argon2id parameters. Servers should use a
+// PasswordHasher so the parameters can be tuned.
 func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	if err != nil {
-		return "", err
-	}
-	return string(hashedPassword), nil
+	return NewPasswordHasher(DefaultArgon2idParams).Hash(password)
 }
 
+// Checks a password against a hash from any algorithm we've ever used
 func CheckPasswordHash(hash, password string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	if err != nil {
-		return err
-	}
-	return nil
+	_, err := NewPasswordHasher(DefaultArgon2idParams).Verify(hash, password)
+	return err
 }
 
 func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
@@ -82,11 +75,5 @@ func GetBearerToken(headers http.Header) (string, error) {
 	// Looking for the "Authorization" header in the headers parameter
 	// returns the TOKEN_STRING if it exists (without Bearer prefix and whitespace)
 	// If header doesn't exist, return an error
-	auths, ok := headers["Authorization"]
-	if !ok {
-		return "", errors.New("Authorization header not found!")
-	}
-	tokenString := strings.Split(auths[0], " ")
-	getJWT := strings.Split(tokenString[1], " ")
-	return getJWT[0], nil
+	return getAuthorization(headers, "Bearer")
 }
@@ -193,3 +193,70 @@ func TestGetBearerTokenInvalid(t *testing.T) {
 		t.Error("Expected there to be no header found, found it anyway")
 	}
 }
+
+// Test one time tokens are random and only the hash matches
+func TestMakeOneTimeToken(t *testing.T) {
+	token, hash, err := MakeOneTimeToken()
+	if err != nil {
+		t.Fatalf("Error making token: %v", err)
+	}
+	otherToken, _, _ := MakeOneTimeToken()
+	if token == otherToken {
+		t.Error("Expected two different tokens")
+	}
+	if hash == token {
+		t.Error("Expected the hash to differ from the token")
+	}
+	if HashOneTimeToken(token) != hash {
+		t.Errorf("Expected: %v\nGot: %v", hash, HashOneTimeToken(token))
+	}
+}
+
+// Test malformed Authorization headers are errors, not panics
+func TestGetBearerTokenMalformed(t *testing.T) {
+	input := []string{
+		"Bearer",
+		"BearerTOKEN_STRING",
+		"Bearer ",
+		"ApiKey TOKEN_STRING",
+	}
+	for i, _ := range input {
+		newHeader := http.Header{}
+		newHeader.Add("Authorization", input[i])
+		_, err := GetBearerToken(newHeader)
+		if err == nil {
+			t.Errorf("Expected error for header %q, got nil", input[i])
+		}
+	}
+}
+
+// Test GetAPIKey
+func TestGetAPIKey(t *testing.T) {
+	newHeader := http.Header{}
+	newHeader.Add("Authorization", "ApiKey f271c81ff7084ee5b99a5091b42d486e")
+
+	apiKey, err := GetAPIKey(newHeader)
+	if err != nil {
+		t.Errorf("ERROR getting api key: %v", err)
+	}
+	if apiKey != "f271c81ff7084ee5b99a5091b42d486e" {
+		t.Errorf("Expected: %v\nGot: %v", "f271c81ff7084ee5b99a5091b42d486e", apiKey)
+	}
+
+	input := []string{
+		"",
+		"ApiKey",
+		"ApiKeyf271c81ff7084ee5b99a5091b42d486e",
+		"Bearer f271c81ff7084ee5b99a5091b42d486e",
+	}
+	for i, _ := range input {
+		newHeader := http.Header{}
+		if input[i] != "" {
+			newHeader.Add("Authorization", input[i])
+		}
+		_, err := GetAPIKey(newHeader)
+		if err == nil {
+			t.Errorf("Expected error for header %q, got nil", input[i])
+		}
+	}
+}
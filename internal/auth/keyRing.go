@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// A key used to sign or verify access tokens. Keys without a private half
+// are retiring: they still verify tokens they signed, but never sign new ones.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// Holds every key we accept access tokens from, and the one we sign with.
+// The legacy HMAC secret (JWT_SECRET) is kept around so tokens issued before
+// the ring was configured stay valid until they expire.
+type KeyRing struct {
+	activeID     string
+	keys         map[string]SigningKey
+	legacySecret string
+}
+
+func NewKeyRing(activeID string, legacySecret string, keys ...SigningKey) (*KeyRing, error) {
+	ring := &KeyRing{
+		activeID:     activeID,
+		keys:         map[string]SigningKey{},
+		legacySecret: legacySecret,
+	}
+	for _, key := range keys {
+		if _, ok := ring.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id: %v", key.ID)
+		}
+		ring.keys[key.ID] = key
+	}
+	if activeID == "" {
+		if legacySecret == "" {
+			return nil, errors.New("no active key id and no legacy secret")
+		}
+		return ring, nil
+	}
+	active, ok := ring.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active key %v not found", activeID)
+	}
+	if active.PrivateKey == nil {
+		return nil, fmt.Errorf("active key %v has no private key", activeID)
+	}
+	return ring, nil
+}
+
+// Loads every *.pem file in dir as a key, using the file name as the key id.
+// Private keys may be PKCS#8 (RSA or Ed25519) or PKCS#1 RSA; a bare PKIX
+// public key is loaded as a retiring, verify-only key.
+func LoadKeyRing(dir, activeID, legacySecret string) (*KeyRing, error) {
+	if dir == "" {
+		return NewKeyRing("", legacySecret)
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	keys := []SigningKey{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keyID := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := ParseSigningKey(keyID, data)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyRing(activeID, legacySecret, keys...)
+}
+
+func ParseSigningKey(keyID string, pemData []byte) (SigningKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return SigningKey{}, errors.New("no PEM block found")
+	}
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported PEM block: %v", block.Type)
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	key := SigningKey{ID: keyID}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.PrivateKey = k
+		key.PublicKey = &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+		key.PublicKey = k
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.PrivateKey = k
+		key.PublicKey = k.Public()
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.PublicKey = k
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported key type: %T", parsed)
+	}
+	return key, nil
+}
+
+// Claims in the access tokens we issue. Scope is a space separated list, as
+// in OAuth 2.0.
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles,omitempty"`
+	Scope string   `json:"scope,omitempty"`
+}
+
+func (ring *KeyRing) MakeJWT(principal Principal, expiresIn time.Duration) (string, error) {
+	now := time.Now().UTC()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "chirpy",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+			Subject:   principal.UserID.String(),
+		},
+		Roles: principal.Roles,
+		Scope: strings.Join(principal.Scopes, " "),
+	}
+
+	if ring.activeID == "" {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(ring.legacySecret))
+	}
+	key := ring.keys[ring.activeID]
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	signedToken, err := token.SignedString(key.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	return signedToken, nil
+}
+
+func (ring *KeyRing) ValidateJWT(tokenString string) (Principal, error) {
+	claims := Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, &claims, ring.verificationKey)
+	if err != nil {
+		return Principal{}, err
+	}
+	if !token.Valid {
+		return Principal{}, fmt.Errorf("invalid token")
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return Principal{}, fmt.Errorf("invalid user ID in token")
+	}
+	// Tokens from before roles existed were all issued to regular users
+	if len(claims.Roles) == 0 && claims.Scope == "" {
+		return NewPrincipal(userID, RoleUser), nil
+	}
+	return Principal{
+		UserID: userID,
+		Roles:  claims.Roles,
+		Scopes: strings.Fields(claims.Scope),
+	}, nil
+}
+
+// Picks the key to check a token against from its kid header. Tokens without
+// a kid can only have been signed with the legacy secret.
+func (ring *KeyRing) verificationKey(token *jwt.Token) (interface{}, error) {
+	keyID, ok := token.Header["kid"].(string)
+	if !ok {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || ring.legacySecret == "" {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(ring.legacySecret), nil
+	}
+	key, ok := ring.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %v", keyID)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.PublicKey, nil
+}
+
+// A JSON Web Key Set (RFC 7517) of every public key in the ring
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+func (ring *KeyRing) JWKS() JWKS {
+	keyIDs := []string{}
+	for keyID := range ring.keys {
+		keyIDs = append(keyIDs, keyID)
+	}
+	sort.Strings(keyIDs)
+
+	set := JWKS{Keys: []JWK{}}
+	for _, keyID := range keyIDs {
+		key := ring.keys[keyID]
+		jwk := JWK{
+			KeyID:     key.ID,
+			Use:       "sig",
+			Algorithm: key.Method.Alg(),
+		}
+		switch k := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
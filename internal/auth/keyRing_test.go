@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func newTestRSAKey(t *testing.T, keyID string) SigningKey {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating RSA key: %v", err)
+	}
+	return SigningKey{ID: keyID, Method: jwt.SigningMethodRS256, PrivateKey: private, PublicKey: &private.PublicKey}
+}
+
+func newTestEd25519Key(t *testing.T, keyID string) SigningKey {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating Ed25519 key: %v", err)
+	}
+	return SigningKey{ID: keyID, Method: jwt.SigningMethodEdDSA, PrivateKey: private, PublicKey: public}
+}
+
+// Make sure tokens signed by each supported key type validate
+func TestKeyRingMakeAndValidateJWT(t *testing.T) {
+	keys := []SigningKey{
+		newTestRSAKey(t, "rsa-1"),
+		newTestEd25519Key(t, "ed-1"),
+	}
+	for i, _ := range keys {
+		ring, err := NewKeyRing(keys[i].ID, "", keys[i])
+		if err != nil {
+			t.Fatalf("Error creating key ring: %v", err)
+		}
+		userID := uuid.New()
+		token, err := ring.MakeJWT(NewPrincipal(userID, RoleUser), time.Minute)
+		if err != nil {
+			t.Fatalf("Error creating token: %v", err)
+		}
+		principal, err := ring.ValidateJWT(token)
+		if err != nil {
+			t.Errorf("Got back the following error: %v", err)
+		}
+		if principal.UserID != userID {
+			t.Errorf("Expected: %v\nGot: %v", userID, principal.UserID)
+		}
+	}
+}
+
+// A retired key must keep validating tokens it signed until they expire
+func TestKeyRingRotation(t *testing.T) {
+	oldKey := newTestEd25519Key(t, "old")
+	newKey := newTestRSAKey(t, "new")
+
+	before, _ := NewKeyRing("old", "", oldKey)
+	token, err := before.MakeJWT(NewPrincipal(uuid.New(), RoleUser), time.Minute)
+	if err != nil {
+		t.Fatalf("Error creating token: %v", err)
+	}
+
+	retiring := oldKey
+	retiring.PrivateKey = nil
+	after, err := NewKeyRing("new", "", newKey, retiring)
+	if err != nil {
+		t.Fatalf("Error creating key ring: %v", err)
+	}
+	if _, err := after.ValidateJWT(token); err != nil {
+		t.Errorf("Expected token from retiring key to validate, got: %v", err)
+	}
+
+	// Once the old key is gone its tokens are rejected
+	removed, _ := NewKeyRing("new", "", newKey)
+	if _, err := removed.ValidateJWT(token); err == nil {
+		t.Error("Expected error for token signed by removed key, got nil")
+	}
+}
+
+// Tokens from before the ring existed carry no kid and use the shared secret
+func TestKeyRingLegacySecret(t *testing.T) {
+	userID := uuid.New()
+	legacyToken, err := MakeJWT(userID, "secret", time.Minute)
+	if err != nil {
+		t.Fatalf("Error creating token: %v", err)
+	}
+
+	ring, _ := NewKeyRing("ed-1", "secret", newTestEd25519Key(t, "ed-1"))
+	principal, err := ring.ValidateJWT(legacyToken)
+	if err != nil {
+		t.Errorf("Got back the following error: %v", err)
+	}
+	if principal.UserID != userID {
+		t.Errorf("Expected: %v\nGot: %v", userID, principal.UserID)
+	}
+	// Tokens from before roles existed get the regular user's scopes
+	if !principal.HasRole(RoleUser) || !principal.HasScope(ScopeChirpsWrite) || principal.HasScope(ScopeAdmin) {
+		t.Errorf("Unexpected principal for legacy token: %+v", principal)
+	}
+
+	noLegacy, _ := NewKeyRing("ed-1", "", newTestEd25519Key(t, "ed-1"))
+	if _, err := noLegacy.ValidateJWT(legacyToken); err == nil {
+		t.Error("Expected error for legacy token without a legacy secret, got nil")
+	}
+}
+
+// Roles and scopes must survive the round trip through the token
+func TestKeyRingRolesAndScopes(t *testing.T) {
+	rings := []*KeyRing{}
+	withKey, _ := NewKeyRing("ed-1", "", newTestEd25519Key(t, "ed-1"))
+	legacyOnly, _ := NewKeyRing("", "secret")
+	rings = append(rings, withKey, legacyOnly)
+
+	for i, _ := range rings {
+		token, err := rings[i].MakeJWT(NewPrincipal(uuid.New(), RoleAdmin), time.Minute)
+		if err != nil {
+			t.Fatalf("Error creating token: %v", err)
+		}
+		principal, err := rings[i].ValidateJWT(token)
+		if err != nil {
+			t.Fatalf("Got back the following error: %v", err)
+		}
+		if !principal.HasRole(RoleAdmin) || !principal.HasScope(ScopeAdmin) || !principal.HasScope(ScopeChirpsWrite) {
+			t.Errorf("Expected admin role and scopes, got %+v", principal)
+		}
+	}
+
+	token, _ := withKey.MakeJWT(NewPrincipal(uuid.New(), RoleUser), time.Minute)
+	principal, _ := withKey.ValidateJWT(token)
+	if principal.HasRole(RoleAdmin) || principal.HasScope(ScopeAdmin) {
+		t.Errorf("Regular user got admin access: %+v", principal)
+	}
+}
+
+func TestNewKeyRingInvalid(t *testing.T) {
+	retiring := newTestEd25519Key(t, "retiring")
+	retiring.PrivateKey = nil
+
+	if _, err := NewKeyRing("", ""); err == nil {
+		t.Error("Expected error for empty key ring, got nil")
+	}
+	if _, err := NewKeyRing("missing", "", newTestEd25519Key(t, "ed-1")); err == nil {
+		t.Error("Expected error for missing active key, got nil")
+	}
+	if _, err := NewKeyRing("retiring", "", retiring); err == nil {
+		t.Error("Expected error for active key without a private key, got nil")
+	}
+}
+
+func TestParseSigningKey(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	privateDER, _ := x509.MarshalPKCS8PrivateKey(private)
+	publicDER, _ := x509.MarshalPKIXPublicKey(public)
+
+	key, err := ParseSigningKey("ed-1", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}))
+	if err != nil {
+		t.Fatalf("Error parsing private key: %v", err)
+	}
+	if key.PrivateKey == nil || key.Method != jwt.SigningMethodEdDSA {
+		t.Errorf("Expected an EdDSA signing key, got %+v", key)
+	}
+
+	key, err = ParseSigningKey("ed-1", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	if err != nil {
+		t.Fatalf("Error parsing public key: %v", err)
+	}
+	if key.PrivateKey != nil {
+		t.Error("Expected a verify-only key from a public key")
+	}
+
+	if _, err := ParseSigningKey("bad", []byte("not a pem file")); err == nil {
+		t.Error("Expected error for malformed PEM, got nil")
+	}
+}
+
+func TestKeyRingJWKS(t *testing.T) {
+	ring, _ := NewKeyRing("a", "secret", newTestRSAKey(t, "a"), newTestEd25519Key(t, "b"))
+	set := ring.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %v", len(set.Keys))
+	}
+	if set.Keys[0].KeyID != "a" || set.Keys[0].KeyType != "RSA" || set.Keys[0].Algorithm != "RS256" || set.Keys[0].E != "AQAB" {
+		t.Errorf("Unexpected RSA key: %+v", set.Keys[0])
+	}
+	if set.Keys[1].KeyID != "b" || set.Keys[1].KeyType != "OKP" || set.Keys[1].Curve != "Ed25519" || set.Keys[1].X == "" {
+		t.Errorf("Unexpected Ed25519 key: %+v", set.Keys[1])
+	}
+}
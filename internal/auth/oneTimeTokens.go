@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Makes a random token to send to a user, along with the hash we store.
+// Only the hash is kept, so a leaked table can't be used to redeem tokens.
+func MakeOneTimeToken() (string, string, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(key)
+	return token, HashOneTimeToken(token), nil
+}
+
+func HashOneTimeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Small parameters so the tests stay fast
+var testArgon2idParams = Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestPasswordHasherArgon2id(t *testing.T) {
+	hasher := NewPasswordHasher(testArgon2idParams)
+	hash, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("Error hashing password: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("Unexpected hash format: %v", hash)
+	}
+	otherHash, _ := hasher.Hash("password")
+	if hash == otherHash {
+		t.Error("Expected a different salt for every hash")
+	}
+
+	needsRehash, err := hasher.Verify(hash, "password")
+	if err != nil {
+		t.Errorf("Got back the following error: %v", err)
+	}
+	if needsRehash {
+		t.Error("Expected a hash with current parameters not to need a rehash")
+	}
+	if _, err := hasher.Verify(hash, "wrongPassword"); err != ErrPasswordMismatch {
+		t.Errorf("Expected ErrPasswordMismatch, got %v", err)
+	}
+}
+
+// Changing the parameters flags old hashes, but they still verify
+func TestPasswordHasherParameterChange(t *testing.T) {
+	hash, _ := NewPasswordHasher(testArgon2idParams).Hash("password")
+
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+	needsRehash, err := NewPasswordHasher(stronger).Verify(hash, "password")
+	if err != nil {
+		t.Errorf("Got back the following error: %v", err)
+	}
+	if !needsRehash {
+		t.Error("Expected a hash with old parameters to need a rehash")
+	}
+}
+
+// Hashes from before argon2id are bcrypt, cost 14
+func TestPasswordHasherBcrypt(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Error hashing password: %v", err)
+	}
+	hasher := NewPasswordHasher(testArgon2idParams)
+	needsRehash, err := hasher.Verify(string(hash), "password")
+	if err != nil {
+		t.Errorf("Got back the following error: %v", err)
+	}
+	if !needsRehash {
+		t.Error("Expected a bcrypt hash to need a rehash")
+	}
+	if _, err := hasher.Verify(string(hash), "wrongPassword"); err != ErrPasswordMismatch {
+		t.Errorf("Expected ErrPasswordMismatch, got %v", err)
+	}
+}
+
+func TestPasswordHasherMalformed(t *testing.T) {
+	hasher := NewPasswordHasher(testArgon2idParams)
+	input := []string{
+		"unset",
+		"$argon2id$v=19$m=1024,t=1,p=1$onlysalt",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$bogus$c2FsdA$a2V5",
+	}
+	for i, _ := range input {
+		if _, err := hasher.Verify(input[i], "password"); err == nil {
+			t.Errorf("Expected error for hash %v, got nil", input[i])
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"github.com/google/uuid"
+)
+
+// Roles stored on the users table
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Scopes carried in access tokens
+const (
+	ScopeChirpsWrite = "chirps:write"
+	ScopeUsersRead   = "users:read"
+	ScopeUsersWrite  = "users:write"
+	ScopeSessions    = "sessions"
+	ScopeAdmin       = "admin"
+	// Only lets the holder finish a two-factor login
+	ScopeMFAChallenge = "mfa:challenge"
+)
+
+var roleScopes = map[string][]string{
+	RoleUser:  {ScopeChirpsWrite, ScopeUsersRead, ScopeUsersWrite, ScopeSessions},
+	RoleAdmin: {ScopeChirpsWrite, ScopeUsersRead, ScopeUsersWrite, ScopeSessions, ScopeAdmin},
+}
+
+// Who an access token was issued to, and what it may do
+type Principal struct {
+	UserID uuid.UUID
+	Roles  []string
+	Scopes []string
+}
+
+// Builds the principal for a user with the given role. Unknown roles get no
+// scopes at all rather than falling back to a default.
+func NewPrincipal(userID uuid.UUID, role string) Principal {
+	scopes := append([]string{}, roleScopes[role]...)
+	return Principal{
+		UserID: userID,
+		Roles:  []string{role},
+		Scopes: scopes,
+	}
+}
+
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B test vectors (SHA1), truncated to 6 digits
+func TestTOTPCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	input := []int64{
+		59,
+		1111111109,
+		1111111111,
+		1234567890,
+		2000000000,
+		20000000000,
+	}
+
+	expected := []string{
+		"287082",
+		"081804",
+		"050471",
+		"005924",
+		"279037",
+		"353130",
+	}
+
+	for i, _ := range input {
+		actual, err := TOTPCode(secret, TOTPStep(time.Unix(input[i], 0)))
+		if err != nil {
+			t.Fatalf("Error making code: %v", err)
+		}
+		if actual != expected[i] {
+			t.Errorf(`TOTPCode(%v) = %v, want %v`, input[i], actual, expected[i])
+		}
+	}
+}
+
+// Codes from the neighbouring steps are accepted, older ones are not
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("Error generating secret: %v", err)
+	}
+	now := time.Now()
+	step := TOTPStep(now)
+
+	current, _ := TOTPCode(secret, step)
+	matched, ok := ValidateTOTP(secret, current, now)
+	if !ok || matched != step {
+		t.Errorf("Expected current code to validate at step %v, got %v %v", step, matched, ok)
+	}
+
+	previous, _ := TOTPCode(secret, step-1)
+	if _, ok := ValidateTOTP(secret, previous, now); !ok {
+		t.Error("Expected code from the previous step to validate")
+	}
+
+	stale, _ := TOTPCode(secret, step-5)
+	if _, ok := ValidateTOTP(secret, stale, now); ok {
+		t.Error("Expected stale code to be rejected")
+	}
+
+	if _, ok := ValidateTOTP(secret, "12345", now); ok {
+		t.Error("Expected short code to be rejected")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("JBSWY3DPEHPK3PXP", "user@example.com", "Chirpy")
+	expected := []string{
+		"otpauth://totp/Chirpy:user@example.com?",
+		"secret=JBSWY3DPEHPK3PXP",
+		"issuer=Chirpy",
+		"digits=6",
+		"period=30",
+	}
+	for i, _ := range expected {
+		if !strings.Contains(uri, expected[i]) {
+			t.Errorf("Expected %v to contain %v", uri, expected[i])
+		}
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("Error generating codes: %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("Expected 10 codes, got %v", len(codes))
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("Unexpected code format: %v", code)
+		}
+		if seen[code] {
+			t.Errorf("Duplicate code: %v", code)
+		}
+		seen[code] = true
+		if NormalizeRecoveryCode(" "+strings.ToUpper(code)+" ") != code {
+			t.Errorf("Expected normalized code to match %v", code)
+		}
+	}
+}
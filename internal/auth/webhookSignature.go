@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signs "<timestamp>.<body>" with HMAC-SHA256. Covering the timestamp means
+// an old request can't be replayed with a fresh timestamp.
+func SignWebhook(secret string, body []byte, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Checks a webhook signature, and that its timestamp (unix seconds) is within
+// tolerance of now in either direction.
+func VerifyWebhookSignature(secret string, body []byte, timestamp, signature string, now time.Time, tolerance time.Duration) error {
+	if timestamp == "" || signature == "" {
+		return errors.New("missing webhook signature")
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("malformed webhook timestamp")
+	}
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > tolerance || age < -tolerance {
+		return errors.New("webhook timestamp outside tolerance")
+	}
+	expected := SignWebhook(secret, body, timestamp)
+	if !hmac.Equal([]byte(expected), []byte(strings.TrimSpace(signature))) {
+		return errors.New("webhook signature mismatch")
+	}
+	return nil
+}
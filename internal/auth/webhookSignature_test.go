@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"user.upgraded","data":{"user_id":"3311741c-680c-4546-99f3-fc9efac2036c"}}`)
+	now := time.Now()
+	timestamp := fmt.Sprint(now.Unix())
+	signature := SignWebhook("secret", body, timestamp)
+
+	err := VerifyWebhookSignature("secret", body, timestamp, signature, now, 5*time.Minute)
+	if err != nil {
+		t.Errorf("Got back the following error: %v", err)
+	}
+
+	old := fmt.Sprint(now.Add(-10 * time.Minute).Unix())
+	type testCase struct {
+		secret    string
+		body      []byte
+		timestamp string
+		signature string
+	}
+	invalid := []testCase{
+		{"wrong-secret", body, timestamp, signature},
+		{"secret", []byte(`{"event":"user.upgraded"}`), timestamp, signature},
+		{"secret", body, old, SignWebhook("secret", body, old)},
+		{"secret", body, "not-a-number", signature},
+		{"secret", body, timestamp, ""},
+		{"secret", body, "", signature},
+	}
+	for i, _ := range invalid {
+		c := invalid[i]
+		if err := VerifyWebhookSignature(c.secret, c.body, c.timestamp, c.signature, now, 5*time.Minute); err == nil {
+			t.Errorf("Expected error for case %v, got nil", i)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+// Test blobs can be written, read back and deleted
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLocalStore(filepath.Join(t.TempDir(), "media"))
+	if err != nil {
+		t.Fatalf("Error creating store: %v", err)
+	}
+	key := "avatars/user/upload/large.jpg"
+	if err := s.Put(ctx, key, []byte("image data")); err != nil {
+		t.Fatalf("Error putting blob: %v", err)
+	}
+	blob, err := s.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Error opening blob: %v", err)
+	}
+	data, _ := io.ReadAll(blob)
+	blob.Close()
+	if string(data) != "image data" {
+		t.Errorf("Expected %q, got %q", "image data", string(data))
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Error deleting blob: %v", err)
+	}
+	if _, err := s.Open(ctx, key); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("Expected deleting a missing blob to succeed, got %v", err)
+	}
+	if _, err := s.Open(ctx, "avatars/user"); err != ErrNotFound {
+		t.Errorf("Expected a directory to be ErrNotFound, got %v", err)
+	}
+}
+
+// Test keys can't reach outside the store's directory
+func TestLocalStoreInvalidKeys(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error creating store: %v", err)
+	}
+	input := []string{
+		"../secret",
+		"/etc/passwd",
+		"avatars/../../secret",
+		`avatars\..\secret`,
+		"",
+		".",
+	}
+	for i, _ := range input {
+		if _, err := s.Open(context.Background(), input[i]); err != ErrInvalidKey {
+			t.Errorf("Open(%q) = %v, want ErrInvalidKey", input[i], err)
+		}
+	}
+}
@@ -16,7 +16,7 @@ WITH deleted AS (
 DELETE FROM chirps
 WHERE id = $1
 AND user_id = $2
-RETURNING id, created_at, updated_at, body, user_id)
+RETURNING id, created_at, updated_at, body, user_id, search_vector, in_reply_to, reply_count, deleted_at, rechirp_of, quote_of, rechirp_count, quote_count, like_count)
 SELECT COUNT(*) FROM deleted
 `
 
@@ -12,7 +12,7 @@ import (
 )
 
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to, reply_count, deleted_at, rechirp_of, quote_of, rechirp_count, quote_count, like_count
 FROM chirps
 WHERE user_id = $1
 `
@@ -26,6 +26,15 @@ func (q *Queries) GetChirp(ctx context.Context, userID uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.LikeCount,
 	)
 	return i, err
 }
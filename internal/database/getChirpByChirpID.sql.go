@@ -12,13 +12,13 @@ import (
 )
 
 const getChirpByChirpID = `-- name: GetChirpByChirpID :one
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to, reply_count, deleted_at, rechirp_of, quote_of, rechirp_count, quote_count, like_count
 FROM chirps
-WHERE user_id = $1
+WHERE id = $1
 `
 
-func (q *Queries) GetChirpByChirpID(ctx context.Context, userID uuid.UUID) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, getChirpByChirpID, userID)
+func (q *Queries) GetChirpByChirpID(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpByChirpID, id)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -26,6 +26,15 @@ func (q *Queries) GetChirpByChirpID(ctx context.Context, userID uuid.UUID) (Chir
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.LikeCount,
 	)
 	return i, err
 }
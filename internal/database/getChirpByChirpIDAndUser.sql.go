@@ -12,7 +12,7 @@ import (
 )
 
 const getChirpByChirpIDAndUserID = `-- name: GetChirpByChirpIDAndUserID :one
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, search_vector, in_reply_to, reply_count, deleted_at, rechirp_of, quote_of, rechirp_count, quote_count, like_count
 FROM chirps
 WHERE id = $1
 AND user_id = $2
@@ -32,6 +32,15 @@ func (q *Queries) GetChirpByChirpIDAndUserID(ctx context.Context, arg GetChirpBy
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.LikeCount,
 	)
 	return i, err
 }
@@ -6,33 +6,217 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type Block struct {
+	BlockerID uuid.UUID `json:"blocker_id"`
+	BlockedID uuid.UUID `json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
+	ID           uuid.UUID     `json:"id"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	Body         string        `json:"body"`
+	UserID       uuid.UUID     `json:"user_id"`
+	SearchVector string        `json:"search_vector"`
+	InReplyTo    uuid.NullUUID `json:"in_reply_to"`
+	ReplyCount   int32         `json:"reply_count"`
+	DeletedAt    sql.NullTime  `json:"deleted_at"`
+	RechirpOf    uuid.NullUUID `json:"rechirp_of"`
+	QuoteOf      uuid.NullUUID `json:"quote_of"`
+	RechirpCount int32         `json:"rechirp_count"`
+	QuoteCount   int32         `json:"quote_count"`
+	LikeCount    int32         `json:"like_count"`
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	HashtagID uuid.UUID `json:"hashtag_id"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
+}
+
+type ChirpLike struct {
 	UserID    uuid.UUID `json:"user_id"`
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
-type RefreshToken struct {
-	Token     string       `json:"token"`
+type ChirpRevision struct {
+	ID         uuid.UUID `json:"id"`
+	ChirpID    uuid.UUID `json:"chirp_id"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+type EmailVerification struct {
+	TokenHash string       `json:"token_hash"`
 	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
 	UserID    uuid.UUID    `json:"user_id"`
+	Email     string       `json:"email"`
 	ExpiresAt time.Time    `json:"expires_at"`
-	RevokedAt sql.NullTime `json:"revoked_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
+type Follow struct {
+	FollowerID uuid.UUID `json:"follower_id"`
+	FolloweeID uuid.UUID `json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Hashtag struct {
+	ID        uuid.UUID `json:"id"`
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type HiddenAuthor struct {
+	ViewerID uuid.UUID `json:"viewer_id"`
+	AuthorID uuid.UUID `json:"author_id"`
+}
+
+type LoginIpFailure struct {
+	IpAddress      string       `json:"ip_address"`
+	FailedAttempts int32        `json:"failed_attempts"`
+	LastFailedAt   time.Time    `json:"last_failed_at"`
+	BlockedUntil   sql.NullTime `json:"blocked_until"`
+}
+
+type Mute struct {
+	MuterID   uuid.UUID `json:"muter_id"`
+	MutedID   uuid.UUID `json:"muted_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Notification struct {
+	ID        uuid.UUID     `json:"id"`
+	UserID    uuid.UUID     `json:"user_id"`
+	ActorID   uuid.UUID     `json:"actor_id"`
+	Type      string        `json:"type"`
+	ChirpID   uuid.NullUUID `json:"chirp_id"`
+	CreatedAt time.Time     `json:"created_at"`
+	ReadAt    sql.NullTime  `json:"read_at"`
+}
+
+type PasswordReset struct {
+	TokenHash string       `json:"token_hash"`
+	CreatedAt time.Time    `json:"created_at"`
+	UserID    uuid.UUID    `json:"user_id"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
+type RecoveryCode struct {
+	CodeHash  string       `json:"code_hash"`
+	UserID    uuid.UUID    `json:"user_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
+type RefreshToken struct {
+	Token      string         `json:"token"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	UserID     uuid.UUID      `json:"user_id"`
+	ExpiresAt  time.Time      `json:"expires_at"`
+	RevokedAt  sql.NullTime   `json:"revoked_at"`
+	FamilyID   uuid.UUID      `json:"family_id"`
+	ReplacedBy sql.NullString `json:"replaced_by"`
+	UserAgent  string         `json:"user_agent"`
+	IpAddress  string         `json:"ip_address"`
+	LastUsedAt time.Time      `json:"last_used_at"`
+}
+
+type Subscription struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	Plan       string       `json:"plan"`
+	Status     string       `json:"status"`
+	StartedAt  time.Time    `json:"started_at"`
+	RenewsAt   sql.NullTime `json:"renews_at"`
+	CanceledAt sql.NullTime `json:"canceled_at"`
+	EndedAt    sql.NullTime `json:"ended_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+type SubscriptionEvent struct {
+	ID             uuid.UUID      `json:"id"`
+	SubscriptionID uuid.UUID      `json:"subscription_id"`
+	UserID         uuid.UUID      `json:"user_id"`
+	EventType      string         `json:"event_type"`
+	Plan           string         `json:"plan"`
+	WebhookEventID sql.NullString `json:"webhook_event_id"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+type TimelineEntry struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	AuthorID  uuid.UUID `json:"author_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TimelineFanout struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TimelineMergedChirp struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	AuthorID  uuid.UUID `json:"author_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TrendingHashtag struct {
+	HashtagID  uuid.UUID `json:"hashtag_id"`
+	Score      float64   `json:"score"`
+	ChirpCount int32     `json:"chirp_count"`
+	ComputedAt time.Time `json:"computed_at"`
 }
 
 type User struct {
-	ID             uuid.UUID    `json:"id"`
-	CreatedAt      time.Time    `json:"created_at"`
-	UpdatedAt      time.Time    `json:"updated_at"`
-	Email          string       `json:"email"`
-	HashedPassword string       `json:"hashed_password"`
-	IsChirpyRed    sql.NullBool `json:"is_chirpy_red"`
+	ID                  uuid.UUID      `json:"id"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	Email               string         `json:"email"`
+	HashedPassword      string         `json:"hashed_password"`
+	IsChirpyRed         sql.NullBool   `json:"is_chirpy_red"`
+	Role                string         `json:"role"`
+	EmailVerifiedAt     sql.NullTime   `json:"email_verified_at"`
+	PendingEmail        sql.NullString `json:"pending_email"`
+	TotpSecret          sql.NullString `json:"totp_secret"`
+	TotpEnabledAt       sql.NullTime   `json:"totp_enabled_at"`
+	TotpLastStep        int64          `json:"totp_last_step"`
+	FailedLoginAttempts int32          `json:"failed_login_attempts"`
+	LastFailedLoginAt   sql.NullTime   `json:"last_failed_login_at"`
+	LockedUntil         sql.NullTime   `json:"locked_until"`
+	FollowerCount       int32          `json:"follower_count"`
+	FollowingCount      int32          `json:"following_count"`
+	Handle              sql.NullString `json:"handle"`
+	DisplayName         string         `json:"display_name"`
+	Bio                 string         `json:"bio"`
+	Location            string         `json:"location"`
+	Website             string         `json:"website"`
+	AvatarKey           sql.NullString `json:"avatar_key"`
+	HeaderKey           sql.NullString `json:"header_key"`
+}
+
+type WebhookEvent struct {
+	Provider    string          `json:"provider"`
+	EventID     string          `json:"event_id"`
+	EventType   string          `json:"event_type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	LastError   sql.NullString  `json:"last_error"`
+	Attempts    int32           `json:"attempts"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	ProcessedAt sql.NullTime    `json:"processed_at"`
+	ClaimedAt   time.Time       `json:"claimed_at"`
 }
@@ -12,19 +12,26 @@ import (
 )
 
 const newRereshToken = `-- name: NewRereshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at, user_agent, ip_address, last_used_at)
 VALUES
-($1, NOW(), NOW(), $2, (NOW() + interval '60' day), NULL)
-RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at
+($1, NOW(), NOW(), $2, (NOW() + interval '60' day), NULL, $3, $4, NOW())
+RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, replaced_by, user_agent, ip_address, last_used_at
 `
 
 type NewRereshTokenParams struct {
-	Token  string    `json:"token"`
-	UserID uuid.UUID `json:"user_id"`
+	Token     string    `json:"token"`
+	UserID    uuid.UUID `json:"user_id"`
+	UserAgent string    `json:"user_agent"`
+	IpAddress string    `json:"ip_address"`
 }
 
 func (q *Queries) NewRereshToken(ctx context.Context, arg NewRereshTokenParams) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, newRereshToken, arg.Token, arg.UserID)
+	row := q.db.QueryRowContext(ctx, newRereshToken,
+		arg.Token,
+		arg.UserID,
+		arg.UserAgent,
+		arg.IpAddress,
+	)
 	var i RefreshToken
 	err := row.Scan(
 		&i.Token,
@@ -33,6 +40,11 @@ func (q *Queries) NewRereshToken(ctx context.Context, arg NewRereshTokenParams)
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
 	)
 	return i, err
 }
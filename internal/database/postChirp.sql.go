@@ -12,19 +12,26 @@ import (
 )
 
 const postChirp = `-- name: PostChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, quote_of)
 VALUES
-(GEN_RANDOM_UUID(), NOW(), NOW(), $1, $2)
-RETURNING id, created_at, updated_at, body, user_id
+(GEN_RANDOM_UUID(), NOW(), NOW(), $1, $2, $3, $4)
+RETURNING id, created_at, updated_at, body, user_id, search_vector, in_reply_to, reply_count, deleted_at, rechirp_of, quote_of, rechirp_count, quote_count, like_count
 `
 
 type PostChirpParams struct {
-	Body   string    `json:"body"`
-	UserID uuid.UUID `json:"user_id"`
+	Body      string        `json:"body"`
+	UserID    uuid.UUID     `json:"user_id"`
+	InReplyTo uuid.NullUUID `json:"in_reply_to"`
+	QuoteOf   uuid.NullUUID `json:"quote_of"`
 }
 
 func (q *Queries) PostChirp(ctx context.Context, arg PostChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, postChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, postChirp,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyTo,
+		arg.QuoteOf,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -32,6 +39,15 @@ func (q *Queries) PostChirp(ctx context.Context, arg PostChirpParams) (Chirp, er
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.LikeCount,
 	)
 	return i, err
 }
@@ -1,24 +0,0 @@
-// Code generated by sqlc. DO NOT EDIT.
-// versions:
-//   sqlc v1.28.0
-// source: upgradeUserToChirpyRed.sql
-
-package database
-
-import (
-	"context"
-
-	"github.com/google/uuid"
-)
-
-const upgradeUserToChirpyRed = `-- name: UpgradeUserToChirpyRed :exec
-UPDATE users
-SET is_chirpy_red = true
-WHERE is_chirpy_red = false
-AND id = $1
-`
-
-func (q *Queries) UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, upgradeUserToChirpyRed, id)
-	return err
-}
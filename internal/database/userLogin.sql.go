@@ -10,7 +10,7 @@ import (
 )
 
 const userLogin = `-- name: UserLogin :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, email_verified_at, pending_email, totp_secret, totp_enabled_at, totp_last_step, failed_login_attempts, last_failed_login_at, locked_until, follower_count, following_count, handle, display_name, bio, location, website, avatar_key, header_key
 FROM users
 WHERE email = $1
 `
@@ -25,6 +25,24 @@ func (q *Queries) UserLogin(ctx context.Context, email string) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.EmailVerifiedAt,
+		&i.PendingEmail,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.FailedLoginAttempts,
+		&i.LastFailedLoginAt,
+		&i.LockedUntil,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
 	)
 	return i, err
 }
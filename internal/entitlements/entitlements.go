@@ -0,0 +1,101 @@
+package entitlements
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Tiers a user can be on. The Chirpy Red tier has the same name as its
+// subscription plan.
+const (
+	TierFree = "free"
+	TierRed  = "chirpy_red"
+)
+
+// Something whose availability depends on the tier. Every feature has a
+// numeric limit; on/off features use 0 for off and 1 for on.
+type Feature string
+
+const (
+	ChirpLength Feature = "chirp_length"
+	UploadBytes Feature = "upload_bytes"
+)
+
+// Used for anything the config file doesn't set
+var Defaults = map[string]map[Feature]int64{
+	TierFree: {
+		ChirpLength: 120,
+		UploadBytes: 1 << 20,
+	},
+	TierRed: {
+		ChirpLength: 1000,
+		UploadBytes: 10 << 20,
+	},
+}
+
+// Which tier gets what
+type Table struct {
+	limits map[string]map[Feature]int64
+}
+
+// Copies limits, so later changes to the map don't leak into the table
+func New(limits map[string]map[Feature]int64) *Table {
+	t := &Table{limits: map[string]map[Feature]int64{}}
+	for tier, features := range limits {
+		t.limits[tier] = map[Feature]int64{}
+		for feature, limit := range features {
+			t.limits[tier][feature] = limit
+		}
+	}
+	return t
+}
+
+// Reads a JSON file of {"tier": {"feature": limit}} over the defaults. An
+// empty path gives the defaults. Unknown tiers and features are errors, so
+// a typo can't quietly leave a limit at its default.
+func Load(path string) (*Table, error) {
+	t := New(Defaults)
+	if path == "" {
+		return t, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	overrides := map[string]map[Feature]int64{}
+	err = json.Unmarshal(data, &overrides)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %v: %w", path, err)
+	}
+	for tier, features := range overrides {
+		if _, ok := t.limits[tier]; !ok {
+			return nil, fmt.Errorf("%v: unknown tier %q", path, tier)
+		}
+		for feature, limit := range features {
+			if _, ok := t.limits[tier][feature]; !ok {
+				return nil, fmt.Errorf("%v: unknown feature %q", path, feature)
+			}
+			if limit < 0 {
+				return nil, fmt.Errorf("%v: %v limit for %v can't be negative", path, feature, tier)
+			}
+			t.limits[tier][feature] = limit
+		}
+	}
+	return t, nil
+}
+
+// The tier's limit for a feature. Unknown tiers get nothing.
+func (t *Table) Limit(tier string, feature Feature) int64 {
+	return t.limits[tier][feature]
+}
+
+// Whether the tier has an on/off feature, or any of a limited one
+func (t *Table) Has(tier string, feature Feature) bool {
+	return t.Limit(tier, feature) > 0
+}
+
+// Whether the tier may use amount of a feature, e.g. a chirp of 300 characters
+func (t *Table) Allows(tier string, feature Feature, amount int64) bool {
+	return amount <= t.Limit(tier, feature)
+}
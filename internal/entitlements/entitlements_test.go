@@ -0,0 +1,74 @@
+package entitlements
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAllows(t *testing.T) {
+	table := New(Defaults)
+	type check struct {
+		tier    string
+		feature Feature
+		amount  int64
+	}
+	input := []check{
+		{TierFree, ChirpLength, 120},
+		{TierFree, ChirpLength, 121},
+		{TierRed, ChirpLength, 121},
+		{TierFree, UploadBytes, 2 << 20},
+		{TierRed, UploadBytes, 2 << 20},
+		{"gold", ChirpLength, 1},
+	}
+
+	expected := []bool{true, false, true, false, true, false}
+
+	for i, _ := range input {
+		actual := table.Allows(input[i].tier, input[i].feature, input[i].amount)
+		if actual != expected[i] {
+			t.Errorf(`Allows(%v) = %v, want %v`, input[i], actual, expected[i])
+		}
+	}
+}
+
+// Values in the file replace the defaults, everything else is left alone
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entitlements.json")
+	err := os.WriteFile(path, []byte(`{"chirpy_red": {"chirp_length": 500}}`), 0o644)
+	if err != nil {
+		t.Fatalf("Error writing config: %v", err)
+	}
+	table, err := Load(path)
+	if err != nil {
+		t.Fatalf("Error loading config: %v", err)
+	}
+	if limit := table.Limit(TierRed, ChirpLength); limit != 500 {
+		t.Errorf("Expected overridden limit 500, got %v", limit)
+	}
+	if limit := table.Limit(TierFree, ChirpLength); limit != Defaults[TierFree][ChirpLength] {
+		t.Errorf("Expected default free limit, got %v", limit)
+	}
+	if Defaults[TierRed][ChirpLength] == 500 {
+		t.Error("Loading a config changed the defaults")
+	}
+}
+
+func TestLoadRejectsUnknownNames(t *testing.T) {
+	input := []string{
+		`{"gold": {"chirp_length": 500}}`,
+		`{"free": {"chirp_lenght": 500}}`,
+		`{"free": {"chirp_length": -1}}`,
+		`not json`,
+	}
+	for i, _ := range input {
+		path := filepath.Join(t.TempDir(), "entitlements.json")
+		err := os.WriteFile(path, []byte(input[i]), 0o644)
+		if err != nil {
+			t.Fatalf("Error writing config: %v", err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("Expected an error loading %v", input[i])
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported image type")
+	ErrTooLarge    = errors.New("image has too many pixels")
+)
+
+const (
+	// Checked against the header before decoding, so a small file claiming
+	// to be a huge image can't use up memory
+	maxPixels = 50_000_000
+	// Images are shrunk to fit this before being turned upright and cropped
+	maxWorkingSide = 2048
+	jpegQuality    = 85
+)
+
+// What uploads are accepted as, going by their content rather than what
+// the client says they are
+var supportedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// One size to produce, e.g. {"small", 48, 48}
+type Size struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// A resized copy, always a JPEG
+type Variant struct {
+	Name string
+	Data []byte
+}
+
+// Sniffs, decodes and turns the image upright, then for each size crops it
+// from the centre to that shape and scales it. The variants are encoded
+// from pixels alone, so no EXIF or other metadata survives. Transparency is
+// flattened onto white. The first frame of an animated GIF is used.
+func Resize(data []byte, sizes []Size) ([]Variant, error) {
+	if !supportedTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupported
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	working := orient(flatten(src), jpegOrientation(data))
+
+	variants := []Variant{}
+	for _, size := range sizes {
+		dst := image.NewRGBA(image.Rect(0, 0, size.Width, size.Height))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), working, centreCrop(working.Bounds(), size), draw.Src, nil)
+		var buf bytes.Buffer
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality})
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, Variant{Name: size.Name, Data: buf.Bytes()})
+	}
+	return variants, nil
+}
+
+// Draws the image onto white, shrinking it to fit maxWorkingSide
+func flatten(src image.Image) *image.RGBA {
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	if width > maxWorkingSide || height > maxWorkingSide {
+		if width >= height {
+			width, height = maxWorkingSide, max(1, height*maxWorkingSide/width)
+		} else {
+			width, height = max(1, width*maxWorkingSide/height), maxWorkingSide
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+	return dst
+}
+
+// The largest rectangle in the middle of bounds with the size's shape
+func centreCrop(bounds image.Rectangle, size Size) image.Rectangle {
+	width, height := bounds.Dx(), bounds.Dy()
+	if width*size.Height > height*size.Width {
+		width = max(1, height*size.Width/size.Height)
+	} else {
+		height = max(1, width*size.Height/size.Width)
+	}
+	x := bounds.Min.X + (bounds.Dx()-width)/2
+	y := bounds.Min.Y + (bounds.Dy()-height)/2
+	return image.Rect(x, y, x+width, y+height)
+}
+
+// Rotates and flips the image by its EXIF orientation (1 to 8), which
+// cameras set instead of storing the pixels upright
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = width-1-x, y
+			case 3:
+				sx, sy = width-1-x, height-1-y
+			case 4:
+				sx, sy = x, height-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, height-1-x
+			case 7:
+				sx, sy = width-1-y, height-1-x
+			case 8:
+				sx, sy = width-1-y, x
+			}
+			dst.SetRGBA(x, y, src.RGBAAt(src.Bounds().Min.X+sx, src.Bounds().Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// The orientation tag from a JPEG's EXIF data, or 1 (upright) when there
+// isn't one or it can't be read
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	i := 2
+	for i+4 <= len(data) && data[i] == 0xFF {
+		marker := data[i+1]
+		// Start of scan: the metadata segments are all before it
+		if marker == 0xDA {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			break
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < count; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		// Orientation, stored as a SHORT
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			return 1
+		}
+	}
+	return 1
+}
@@ -0,0 +1,111 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Error encoding png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// A JPEG with an APP1 segment holding just an orientation tag
+func jpegWithOrientation(t *testing.T, img image.Image, orientation byte) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("Error encoding jpeg: %v", err)
+	}
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00")
+	exif = append(exif, orientation, 0, 0, 0, 0, 0, 0)
+	segment := []byte{0xFF, 0xE1, byte((len(exif) + 2) >> 8), byte(len(exif) + 2)}
+	data := append([]byte{0xFF, 0xD8}, segment...)
+	data = append(data, exif...)
+	return append(data, buf.Bytes()[2:]...)
+}
+
+// Test every size comes back as a JPEG of exactly that size
+func TestResize(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 100))
+	sizes := []Size{{"small", 48, 48}, {"wide", 120, 40}}
+	variants, err := Resize(encodePNG(t, src), sizes)
+	if err != nil {
+		t.Fatalf("Error resizing: %v", err)
+	}
+	if len(variants) != len(sizes) {
+		t.Fatalf("Expected %v variants, got %v", len(sizes), len(variants))
+	}
+	for i, _ := range sizes {
+		config, format, err := image.DecodeConfig(bytes.NewReader(variants[i].Data))
+		if err != nil {
+			t.Fatalf("Error decoding %v: %v", sizes[i].Name, err)
+		}
+		if variants[i].Name != sizes[i].Name || format != "jpeg" || config.Width != sizes[i].Width || config.Height != sizes[i].Height {
+			t.Errorf("Expected a %vx%v jpeg called %v, got a %vx%v %v called %v", sizes[i].Width, sizes[i].Height, sizes[i].Name, config.Width, config.Height, format, variants[i].Name)
+		}
+	}
+}
+
+// Test uploads are checked by content, and huge images are refused up front
+func TestResizeRejects(t *testing.T) {
+	sizes := []Size{{"small", 48, 48}}
+	if _, err := Resize([]byte("<svg xmlns='http://www.w3.org/2000/svg'></svg>"), sizes); err != ErrUnsupported {
+		t.Errorf("Expected ErrUnsupported for svg, got %v", err)
+	}
+	// The IHDR chunk says 20000x20000; only the header is read, so the
+	// missing pixel data doesn't matter
+	huge := encodePNG(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+	huge[16], huge[17], huge[18], huge[19] = 0, 0, 0x4E, 0x20
+	huge[20], huge[21], huge[22], huge[23] = 0, 0, 0x4E, 0x20
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+	if _, err := Resize(huge, sizes); err != ErrTooLarge {
+		t.Errorf("Expected ErrTooLarge for a 20000x20000 png, got %v", err)
+	}
+}
+
+// Test EXIF orientation is applied and the EXIF data dropped
+func TestResizeOrientation(t *testing.T) {
+	// Left half red, right half blue; rotated a quarter turn clockwise
+	// (orientation 6), red ends up on top
+	src := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			if x < 32 {
+				src.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				src.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	data := jpegWithOrientation(t, src, 6)
+	if orientation := jpegOrientation(data); orientation != 6 {
+		t.Fatalf("Expected orientation 6, got %v", orientation)
+	}
+	variants, err := Resize(data, []Size{{"tall", 32, 64}})
+	if err != nil {
+		t.Fatalf("Error resizing: %v", err)
+	}
+	if bytes.Contains(variants[0].Data, []byte("Exif")) {
+		t.Errorf("Expected the EXIF data to be stripped")
+	}
+	img, err := jpeg.Decode(bytes.NewReader(variants[0].Data))
+	if err != nil {
+		t.Fatalf("Error decoding: %v", err)
+	}
+	top, bottom := img.At(16, 8), img.At(16, 56)
+	if r, _, b, _ := top.RGBA(); r < b {
+		t.Errorf("Expected red at the top, got %v", top)
+	}
+	if r, _, b, _ := bottom.RGBA(); b < r {
+		t.Errorf("Expected blue at the bottom, got %v", bottom)
+	}
+}
@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Anything that can deliver an email to a user
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Writes every message as a .eml file to a local directory instead of
+// sending it, so mail flows can be tested without an SMTP server.
+type OutboxMailer struct {
+	Dir  string
+	From string
+}
+
+func NewOutboxMailer(dir, from string) (*OutboxMailer, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxMailer{Dir: dir, From: from}, nil
+}
+
+func (m *OutboxMailer) Send(ctx context.Context, msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("invalid header value")
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	now := time.Now().UTC()
+	name := fmt.Sprintf("%v-%v.eml", now.Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %v\r\n", m.From)
+	fmt.Fprintf(&b, "To: %v\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %v\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %v\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+
+	return os.WriteFile(filepath.Join(m.Dir, name), []byte(b.String()), 0o644)
+}
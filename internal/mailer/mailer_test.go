@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Test messages land in the outbox with their headers and body
+func TestOutboxMailerSend(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	m, err := NewOutboxMailer(dir, "chirpy@localhost")
+	if err != nil {
+		t.Fatalf("Error creating mailer: %v", err)
+	}
+	msg := Message{To: "user@example.com", Subject: "Hello", Body: "Some body"}
+	for i := 0; i < 2; i++ {
+		if err := m.Send(context.Background(), msg); err != nil {
+			t.Fatalf("Error sending: %v", err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 messages in the outbox, got %v", len(files))
+	}
+	data, _ := os.ReadFile(files[0])
+	expected := []string{
+		"From: chirpy@localhost\r\n",
+		"To: user@example.com\r\n",
+		"Subject: Hello\r\n",
+		"\r\n\r\nSome body",
+	}
+	for i, _ := range expected {
+		if !strings.Contains(string(data), expected[i]) {
+			t.Errorf("Expected message to contain %q, got:\n%v", expected[i], string(data))
+		}
+	}
+}
+
+// Test header injection is rejected
+func TestOutboxMailerRejectsNewlines(t *testing.T) {
+	m, _ := NewOutboxMailer(t.TempDir(), "chirpy@localhost")
+	msg := Message{To: "user@example.com\r\nBcc: someone@example.com", Subject: "Hello", Body: "Some body"}
+	if err := m.Send(context.Background(), msg); err == nil {
+		t.Error("Expected error for newline in header, got nil")
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Each like is a row in chirp_likes, and chirps.like_count is changed in the
+// same transaction whenever one is added or removed. Liking and unliking are
+// idempotent. Likes on a rechirp go to the original.
+
+func (cfg *apiConfig) likeChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad chirp ID")
+		return
+	}
+	userID := requestPrincipal(r).UserID
+
+	ctx := context.Background()
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, 500, "Unable to like chirp")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	originalID, err := originalChirpID(ctx, qtx, chirpID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: loading chirp %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to like chirp")
+		return
+	}
+	blocked, err := isBlockedFromChirp(ctx, qtx, userID, originalID)
+	if err != nil {
+		log.Printf("ERROR: checking blocks on %v: %v", originalID, err)
+		respondWithError(w, 500, "Unable to like chirp")
+		return
+	}
+	if blocked {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	// Counting first locks the chirp, so concurrent likes queue up behind us
+	// and it can't be deleted before the like is in
+	updated, err := qtx.IncrementLikeCount(ctx, originalID)
+	if err != nil {
+		log.Printf("ERROR: counting like of %v: %v", originalID, err)
+		respondWithError(w, 500, "Unable to like chirp")
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	created, err := qtx.CreateChirpLike(ctx, database.CreateChirpLikeParams{
+		UserID:  userID,
+		ChirpID: originalID,
+	})
+	if err != nil {
+		log.Printf("ERROR: liking %v: %v", originalID, err)
+		respondWithError(w, 500, "Unable to like chirp")
+		return
+	}
+	if created == 0 {
+		// Already liked; rolling back undoes the count
+		w.WriteHeader(204)
+		return
+	}
+	err = qtx.NotifyLike(ctx, database.NotifyLikeParams{
+		ActorID: userID,
+		ChirpID: originalID,
+	})
+	if err != nil {
+		log.Printf("ERROR: notifying about like of %v: %v", originalID, err)
+		respondWithError(w, 500, "Unable to like chirp")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Unable to like chirp")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) unlikeChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad chirp ID")
+		return
+	}
+	userID := requestPrincipal(r).UserID
+
+	ctx := context.Background()
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, 500, "Unable to unlike chirp")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	originalID, err := originalChirpID(ctx, qtx, chirpID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: loading chirp %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to unlike chirp")
+		return
+	}
+	// Only one of two concurrent unlikes can delete the row
+	deleted, err := qtx.DeleteChirpLike(ctx, database.DeleteChirpLikeParams{
+		UserID:  userID,
+		ChirpID: originalID,
+	})
+	if err != nil {
+		log.Printf("ERROR: unliking %v: %v", originalID, err)
+		respondWithError(w, 500, "Unable to unlike chirp")
+		return
+	}
+	if deleted == 0 {
+		w.WriteHeader(204)
+		return
+	}
+	err = qtx.DecrementLikeCount(ctx, originalID)
+	if err != nil {
+		log.Printf("ERROR: uncounting like of %v: %v", originalID, err)
+		respondWithError(w, 500, "Unable to unlike chirp")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Unable to unlike chirp")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// Sets liked_by_me on each chirp for the given user
+func (cfg *apiConfig) markLikedChirps(ctx context.Context, userID uuid.UUID, chirps []*Chirp) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.ID)
+	}
+	liked, err := cfg.dbQuerries.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+		UserID:   userID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return err
+	}
+	likedIDs := map[uuid.UUID]bool{}
+	for _, id := range liked {
+		likedIDs[id] = true
+	}
+	for _, chirp := range chirps {
+		likedByMe := likedIDs[chirp.ID]
+		chirp.LikedByMe = &likedByMe
+	}
+	return nil
+}
+
+// Same shape as a chirp cursor, but keyed on when the chirp was liked
+func encodeLikeCursor(row database.ListLikedChirpsRow) string {
+	data, _ := json.Marshal(chirpCursor{CreatedAt: row.LikedAt, ID: row.Chirp.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// What a user has liked, most recently liked first
+func (cfg *apiConfig) getUserLikes(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad user ID")
+		return
+	}
+	query := r.URL.Query()
+	limit, err := pageSize(query)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	params := database.ListLikedChirpsParams{
+		UserID:     userID,
+		ViewerID:   requestPrincipal(r).UserID,
+		MaxResults: limit + 1,
+	}
+	if cursorParam := query.Get("cursor"); cursorParam != "" {
+		cursor, err := decodeChirpCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, 400, err.Error())
+			return
+		}
+		params.AfterLikedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	ctx := context.Background()
+	_, err = cfg.dbQuerries.GetUserById(ctx, userID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: loading user %v: %v", userID, err)
+		respondWithError(w, 500, "Unable to get likes")
+		return
+	}
+	rows, err := cfg.dbQuerries.ListLikedChirps(ctx, params)
+	if err != nil {
+		log.Printf("ERROR: listing likes of %v: %v", userID, err)
+		respondWithError(w, 500, "Unable to get likes")
+		return
+	}
+	if len(rows) > int(limit) {
+		rows = rows[:limit]
+		setNextPage(w, r, encodeLikeCursor(rows[len(rows)-1]))
+	}
+
+	likes := []LikedChirp{}
+	for _, row := range rows {
+		likes = append(likes, LikedChirp{
+			Chirp:   chirpResponse(row.Chirp),
+			LikedAt: row.LikedAt,
+		})
+	}
+	embedded := []*Chirp{}
+	for i := range likes {
+		embedded = append(embedded, &likes[i].Chirp)
+	}
+	err = cfg.prepareChirps(ctx, r, embedded)
+	if err != nil {
+		log.Printf("ERROR: loading liked chirps of %v: %v", userID, err)
+		respondWithError(w, 500, "Unable to get likes")
+		return
+	}
+	respondWithJSON(w, 200, likes)
+}
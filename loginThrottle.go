@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// How long to block logins after a run of failures. The first few failures
+// are free, then every further one doubles the wait up to maxDelay.
+type throttlePolicy struct {
+	freeAttempts int32
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+}
+
+// A few typos are fine, but guessing at one account quickly locks it
+var accountThrottle = throttlePolicy{freeAttempts: 5, baseDelay: 30 * time.Second, maxDelay: time.Hour}
+
+// Looser, since many users can share an address, but still catches one
+// client guessing passwords across lots of accounts
+var ipThrottle = throttlePolicy{freeAttempts: 20, baseDelay: 10 * time.Second, maxDelay: 30 * time.Minute}
+
+func (p throttlePolicy) delay(failures int32) time.Duration {
+	if failures < p.freeAttempts {
+		return 0
+	}
+	delay := p.baseDelay
+	for i := p.freeAttempts; i < failures; i++ {
+		delay *= 2
+		if delay >= p.maxDelay {
+			return p.maxDelay
+		}
+	}
+	return delay
+}
+
+func durationSeconds(d time.Duration) int32 {
+	return int32(math.Ceil(d.Seconds()))
+}
+
+// Seconds until this address may try to log in again, 0 if it may now
+func (cfg *apiConfig) ipRetryAfter(ctx context.Context, ip string) (int32, error) {
+	retryAfter, err := cfg.dbQuerries.GetIPBlockSeconds(ctx, ip)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return retryAfter, err
+}
+
+// Seconds until this account may try to log in again, 0 if it may now
+func (cfg *apiConfig) accountRetryAfter(ctx context.Context, userID uuid.UUID) (int32, error) {
+	retryAfter, err := cfg.dbQuerries.GetUserLockoutSeconds(ctx, userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return retryAfter, err
+}
+
+// Counts a failed login against the address and, if we know who they were
+// trying to be, the account. Pass uuid.Nil when the email didn't match.
+func (cfg *apiConfig) recordFailedLogin(ctx context.Context, ip string, userID uuid.UUID) {
+	failures, err := cfg.dbQuerries.RecordFailedLoginForIP(ctx, ip)
+	if err != nil {
+		log.Printf("ERROR: recording failed login for %v: %v", ip, err)
+	} else if delay := ipThrottle.delay(failures); delay > 0 {
+		err = cfg.dbQuerries.BlockIP(ctx, database.BlockIPParams{
+			BlockSeconds: durationSeconds(delay),
+			IpAddress:    ip,
+		})
+		if err != nil {
+			log.Printf("ERROR: blocking %v: %v", ip, err)
+		}
+	}
+
+	if userID == uuid.Nil {
+		return
+	}
+	failures, err = cfg.dbQuerries.RecordFailedLogin(ctx, userID)
+	if err != nil {
+		log.Printf("ERROR: recording failed login for %v: %v", userID, err)
+	} else if delay := accountThrottle.delay(failures); delay > 0 {
+		log.Printf("WARNING: locking account %v for %v after %v failed logins", userID, delay, failures)
+		err = cfg.dbQuerries.LockUser(ctx, database.LockUserParams{
+			LockSeconds: durationSeconds(delay),
+			ID:          userID,
+		})
+		if err != nil {
+			log.Printf("ERROR: locking %v: %v", userID, err)
+		}
+	}
+}
+
+func respondWithRetryAfter(w http.ResponseWriter, retryAfter int32) {
+	w.Header().Set("Retry-After", fmt.Sprint(retryAfter))
+	respondWithError(w, 429, "Too many failed login attempts, try again later")
+}
+
+// Lets an admin clear a lockout, e.g. after someone else hammered the account
+func (cfg *apiConfig) unlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad user ID")
+		return
+	}
+	ctx := context.Background()
+	unlocked, err := cfg.dbQuerries.ResetFailedLogins(ctx, userID)
+	if err != nil {
+		log.Printf("ERROR: unlocking %v: %v", userID, err)
+		respondWithError(w, 500, "Unable to unlock user")
+		return
+	}
+	if unlocked == 0 {
+		respondWithError(w, 404, "User does not exist")
+		return
+	}
+	log.Printf("Admin %v unlocked user %v", requestPrincipal(r).UserID, userID)
+	w.WriteHeader(204)
+}
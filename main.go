@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
+	auth "github.com/avgra3/chirpy/internal/auth"
+	"github.com/avgra3/chirpy/internal/blobstore"
 	"github.com/avgra3/chirpy/internal/database"
+	"github.com/avgra3/chirpy/internal/entitlements"
+	"github.com/avgra3/chirpy/internal/mailer"
 	"github.com/google/uuid"
 	_ "github.com/google/uuid"
 	"github.com/joho/godotenv"
@@ -20,12 +27,58 @@ func main() {
 	currentPlatform := os.Getenv("PLATFORM")
 	dbURL := os.Getenv("DB_URL")
 	jwtSecret := os.Getenv("JWT_SECRET")
+	keyRing, err := auth.LoadKeyRing(os.Getenv("JWT_KEYS_DIR"), os.Getenv("JWT_ACTIVE_KEY_ID"), jwtSecret)
+	if err != nil {
+		log.Fatal(err)
+	}
+	argon2Params := auth.DefaultArgon2idParams
+	argon2Params.Memory = uint32(uintFromEnv("ARGON2_MEMORY_KIB", uint64(argon2Params.Memory), 32))
+	argon2Params.Iterations = uint32(uintFromEnv("ARGON2_ITERATIONS", uint64(argon2Params.Iterations), 32))
+	argon2Params.Parallelism = uint8(uintFromEnv("ARGON2_PARALLELISM", uint64(argon2Params.Parallelism), 8))
 	db, err := sql.Open("postgres", dbURL)
-	apiKey := os.Getenv("POLA_KEY")
+	apiKey := os.Getenv("POLKA_KEY")
+	if apiKey == "" {
+		// Older .env files have the misspelt name
+		apiKey = os.Getenv("POLA_KEY")
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 	dbQuerries := database.New(db)
+	// Until we have a real mail provider, emails are written to a directory
+	outboxDir := os.Getenv("MAIL_OUTBOX_DIR")
+	if outboxDir == "" {
+		outboxDir = "./outbox"
+	}
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "chirpy@localhost"
+	}
+	outbox, err := mailer.NewOutboxMailer(outboxDir, mailFrom)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Uploaded images live on local disk until there's another blob store
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "./media"
+	}
+	blobs, err := blobstore.NewLocalStore(mediaDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tiers, err := entitlements.Load(os.Getenv("ENTITLEMENTS_FILE"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	chirpEditWindow := time.Duration(uintFromEnv("CHIRP_EDIT_WINDOW_SECONDS", 30*60, 32)) * time.Second
+	// Capped so the period in seconds fits in an INT
+	subscriptionPeriod := time.Duration(uintFromEnv("SUBSCRIPTION_PERIOD_DAYS", 30, 14)) * 24 * time.Hour
+	// Chirps from accounts this big are merged into timelines when read
+	// instead of being pushed to every follower
+	fanoutMaxFollowers := int32(uintFromEnv("TIMELINE_FANOUT_MAX_FOLLOWERS", 10000, 31))
 
 	// Setting up our server
 	serverMux := http.NewServeMux()
@@ -36,30 +89,121 @@ func main() {
 	// Fileserver uses the http.Dir to map the
 	// current directory to http address.
 	apiCfg := apiConfig{
-		dbQuerries: dbQuerries,
-		platform:   currentPlatform,
-		jwtSecret:  jwtSecret,
-		polkaKey:   apiKey,
+		dbQuerries:         dbQuerries,
+		db:                 db,
+		platform:           currentPlatform,
+		keyRing:            keyRing,
+		passwordHasher:     auth.NewPasswordHasher(argon2Params),
+		polkaKey:           apiKey,
+		polkaWebhookSecret: os.Getenv("POLKA_WEBHOOK_SECRET"),
+		mailer:             outbox,
+		entitlements:       tiers,
+		chirpEditWindow:    chirpEditWindow,
+		subscriptionPeriod: subscriptionPeriod,
+		fanoutMaxFollowers: fanoutMaxFollowers,
+		fanoutWake:         make(chan struct{}, 1),
+		blobs:              blobs,
+	}
+	// ./bin/out rebuild-timelines [userID...] rebuilds the timeline cache, for
+	// every user when none are given, then exits
+	if len(os.Args) > 1 && os.Args[1] == "rebuild-timelines" {
+		userIDs := []uuid.UUID{}
+		for _, arg := range os.Args[2:] {
+			userID, err := uuid.Parse(arg)
+			if err != nil {
+				log.Fatalf("Bad user ID %q", arg)
+			}
+			userIDs = append(userIDs, userID)
+		}
+		err = apiCfg.rebuildTimelines(context.Background(), userIDs)
+		if err != nil {
+			log.Fatalf("Rebuilding timelines: %v", err)
+		}
+		log.Printf("Rebuilt timelines")
+		return
 	}
+	// Ends subscriptions Polka stopped renewing
+	expiryInterval := time.Duration(uintFromEnv("SUBSCRIPTION_EXPIRY_INTERVAL_SECONDS", 300, 32)) * time.Second
+	go apiCfg.runSubscriptionExpiry(expiryInterval)
+	// Scores hashtags used recently, halving each use's weight every half life
+	trendingInterval := time.Duration(uintFromEnv("TRENDING_INTERVAL_SECONDS", 60, 32)) * time.Second
+	trendingWindow := time.Duration(uintFromEnv("TRENDING_WINDOW_SECONDS", 24*60*60, 32)) * time.Second
+	trendingHalfLife := time.Duration(uintFromEnv("TRENDING_HALF_LIFE_SECONDS", 2*60*60, 32)) * time.Second
+	go apiCfg.runTrendingHashtags(trendingInterval, trendingWindow, trendingHalfLife)
+	// Pushes new chirps to followers' timelines
+	fanoutInterval := time.Duration(uintFromEnv("TIMELINE_FANOUT_INTERVAL_SECONDS", 5, 32)) * time.Second
+	go apiCfg.runTimelineFanout(fanoutInterval)
+
 	app := http.StripPrefix("/app", http.FileServer(http.Dir(".")))
 	serverMux.Handle("/app/", apiCfg.middlewareMetricsInt(app))
+	// Uploaded avatars and headers
+	serverMux.HandleFunc("GET /media/{key...}", apiCfg.serveMedia)
 
 	// Handle hits to the file server
 	serverMux.HandleFunc("GET /admin/metrics", apiCfg.adminHandler)
 	serverMux.HandleFunc("POST /admin/reset", apiCfg.resetCounter)
+	serverMux.HandleFunc("POST /admin/users/{userID}/unlock", apiCfg.requireAuth(apiCfg.unlockUser, auth.ScopeAdmin))
+	// Public keys for services verifying our access tokens
+	serverMux.HandleFunc("GET /.well-known/jwks.json", apiCfg.jwks)
 	// serverMux.HandleFunc("POST /api/validate_chirp", validateChirpLength)
 	serverMux.HandleFunc("POST /api/login", apiCfg.userLogin)
+	serverMux.HandleFunc("POST /api/login/2fa", apiCfg.loginSecondFactor)
 	serverMux.HandleFunc("POST /api/users", apiCfg.newUserHandler)
-	serverMux.HandleFunc("POST /api/chirps", apiCfg.newChirps)
-	serverMux.HandleFunc("GET /api/chirps", apiCfg.getChirps)
-	serverMux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.getChirp)
+	serverMux.HandleFunc("POST /api/chirps", apiCfg.requireAuth(apiCfg.newChirps, auth.ScopeChirpsWrite))
+	serverMux.HandleFunc("GET /api/chirps", apiCfg.optionalAuth(apiCfg.getChirps))
+	serverMux.HandleFunc("GET /api/chirps/search", apiCfg.optionalAuth(apiCfg.searchChirps))
+	serverMux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.optionalAuth(apiCfg.getChirp))
 	serverMux.HandleFunc("POST /api/refresh", apiCfg.refreshToken)
 	serverMux.HandleFunc("POST /api/revoke", apiCfg.revokeToken)
-	serverMux.HandleFunc("PUT /api/users", apiCfg.updateEmailPassword)
-	serverMux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.deleteChirp)
+	serverMux.HandleFunc("GET /api/notifications", apiCfg.requireAuth(apiCfg.listNotifications, auth.ScopeUsersRead))
+	serverMux.HandleFunc("POST /api/notifications/read", apiCfg.requireAuth(apiCfg.markNotificationsRead, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("GET /api/users/me/subscription", apiCfg.requireAuth(apiCfg.getSubscription, auth.ScopeUsersRead))
+	serverMux.HandleFunc("POST /api/users/verify-email", apiCfg.verifyEmail)
+	serverMux.HandleFunc("POST /api/users/verify-email/resend", apiCfg.requireAuth(apiCfg.resendEmailVerification, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("POST /api/users/2fa/totp", apiCfg.requireAuth(apiCfg.enrollTOTP, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("POST /api/users/2fa/totp/confirm", apiCfg.requireAuth(apiCfg.confirmTOTP, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("DELETE /api/users/2fa/totp", apiCfg.requireAuth(apiCfg.disableTOTP, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("POST /api/password-reset", apiCfg.requestPasswordReset)
+	serverMux.HandleFunc("POST /api/password-reset/confirm", apiCfg.confirmPasswordReset)
+	serverMux.HandleFunc("PUT /api/users", apiCfg.requireAuth(apiCfg.updateEmailPassword, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("PATCH /api/users/me", apiCfg.requireAuth(apiCfg.updateProfile, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("GET /api/users/{user}", apiCfg.getUserProfile)
+	serverMux.HandleFunc("PUT /api/users/me/avatar", apiCfg.requireAuth(apiCfg.uploadAvatar, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("DELETE /api/users/me/avatar", apiCfg.requireAuth(apiCfg.deleteAvatar, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("PUT /api/users/me/header", apiCfg.requireAuth(apiCfg.uploadHeader, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("DELETE /api/users/me/header", apiCfg.requireAuth(apiCfg.deleteHeader, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("PUT /api/chirps/{chirpID}", apiCfg.requireAuth(apiCfg.editChirp, auth.ScopeChirpsWrite))
+	serverMux.HandleFunc("GET /api/chirps/{chirpID}/history", apiCfg.optionalAuth(apiCfg.getChirpHistory))
+	serverMux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiCfg.optionalAuth(apiCfg.getChirpThread))
+	serverMux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", apiCfg.requireAuth(apiCfg.rechirp, auth.ScopeChirpsWrite))
+	serverMux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", apiCfg.requireAuth(apiCfg.undoRechirp, auth.ScopeChirpsWrite))
+	serverMux.HandleFunc("POST /api/chirps/{chirpID}/likes", apiCfg.requireAuth(apiCfg.likeChirp, auth.ScopeChirpsWrite))
+	serverMux.HandleFunc("DELETE /api/chirps/{chirpID}/likes", apiCfg.requireAuth(apiCfg.unlikeChirp, auth.ScopeChirpsWrite))
+	serverMux.HandleFunc("GET /api/users/{userID}/likes", apiCfg.optionalAuth(apiCfg.getUserLikes))
+	serverMux.HandleFunc("POST /api/users/{userID}/follow", apiCfg.requireAuth(apiCfg.followUser, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.requireAuth(apiCfg.unfollowUser, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("GET /api/users/{userID}/followers", apiCfg.listFollowers)
+	serverMux.HandleFunc("GET /api/users/{userID}/following", apiCfg.listFollowing)
+	serverMux.HandleFunc("POST /api/users/{userID}/block", apiCfg.requireAuth(apiCfg.blockUser, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("DELETE /api/users/{userID}/block", apiCfg.requireAuth(apiCfg.unblockUser, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("POST /api/users/{userID}/mute", apiCfg.requireAuth(apiCfg.muteUser, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("DELETE /api/users/{userID}/mute", apiCfg.requireAuth(apiCfg.unmuteUser, auth.ScopeUsersWrite))
+	serverMux.HandleFunc("GET /api/users/me/blocks", apiCfg.requireAuth(apiCfg.listBlocks, auth.ScopeUsersRead))
+	serverMux.HandleFunc("GET /api/users/me/mutes", apiCfg.requireAuth(apiCfg.listMutes, auth.ScopeUsersRead))
+	serverMux.HandleFunc("GET /api/timeline", apiCfg.requireAuth(apiCfg.getTimeline, auth.ScopeUsersRead))
+	serverMux.HandleFunc("GET /api/hashtags/{tag}/chirps", apiCfg.optionalAuth(apiCfg.getHashtagChirps))
+	serverMux.HandleFunc("GET /api/trending", apiCfg.getTrendingHashtags)
+	serverMux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.requireAuth(apiCfg.deleteChirp, auth.ScopeChirpsWrite))
+
+	// Sessions (one per login, tracked by refresh token family)
+	serverMux.HandleFunc("GET /api/sessions", apiCfg.requireAuth(apiCfg.listSessions, auth.ScopeSessions))
+	serverMux.HandleFunc("DELETE /api/sessions/{sessionID}", apiCfg.requireAuth(apiCfg.revokeSession, auth.ScopeSessions))
+	serverMux.HandleFunc("POST /api/sessions/revoke-all", apiCfg.requireAuth(apiCfg.revokeAllSessions, auth.ScopeSessions))
 
 	// Webhooks
 	serverMux.HandleFunc("POST /api/polka/webhooks", apiCfg.upgradeUserToRed)
+	serverMux.HandleFunc("GET /admin/webhooks/polka", apiCfg.requireAuth(apiCfg.listPolkaEvents, auth.ScopeAdmin))
+	serverMux.HandleFunc("POST /admin/webhooks/polka/{eventID}/replay", apiCfg.requireAuth(apiCfg.replayPolkaEvent, auth.ScopeAdmin))
 
 	server := http.Server{
 		Handler: serverMux,
@@ -69,6 +213,19 @@ func main() {
 	server.ListenAndServe()
 }
 
+// Reads an optional unsigned number from the environment
+func uintFromEnv(name string, fallback uint64, bitSize int) uint64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseUint(value, 10, bitSize)
+	if err != nil || parsed == 0 {
+		log.Fatalf("%v must be a positive number, got %q", name, value)
+	}
+	return parsed
+}
+
 type User struct {
 	ID             uuid.UUID `json:"id"`
 	CreatedAt      time.Time `json:"created_at"`
@@ -78,14 +235,148 @@ type User struct {
 	Token          string    `json:"token"`
 	RefreshToken   string    `json:"refresh_token"`
 	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	EmailVerified  bool      `json:"email_verified"`
+	PendingEmail   string    `json:"pending_email,omitempty"`
 }
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID           uuid.UUID  `json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Body         string     `json:"body"`
+	UserID       uuid.UUID  `json:"user_id"`
+	InReplyTo    *uuid.UUID `json:"in_reply_to"`
+	ReplyCount   int32      `json:"reply_count"`
+	RechirpOf    *uuid.UUID `json:"rechirp_of"`
+	QuoteOf      *uuid.UUID `json:"quote_of"`
+	RechirpCount int32      `json:"rechirp_count"`
+	QuoteCount   int32      `json:"quote_count"`
+	LikeCount    int32      `json:"like_count"`
+	// Only sent to signed-in callers
+	LikedByMe *bool `json:"liked_by_me,omitempty"`
+	// The chirp a rechirp or quote points at
+	Original *Chirp `json:"original,omitempty"`
+	// By someone the caller has blocked or muted, or who has blocked them;
+	// sent without a body
+	Hidden bool `json:"hidden,omitempty"`
+	// Deleted chirps with replies are kept as an empty tombstone
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// A chirp in a user's liked list
+type LikedChirp struct {
+	Chirp
+	LikedAt time.Time `json:"liked_at"`
+}
+
+// Something another user did that involves you: "mention", "reply", "like"
+// or "follow". The chirp is the one that mentions you, the reply, or the
+// chirp that was liked; follows have none.
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	ActorID   uuid.UUID  `json:"actor_id"`
+	ChirpID   *uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+// Someone in a follower or following list, and when the follow started
+type Follow struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// What anyone can see about a user. Never includes their email.
+type Profile struct {
+	ID             uuid.UUID `json:"id"`
+	Handle         *string   `json:"handle"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	Location       string    `json:"location"`
+	Website        string    `json:"website"`
+	FollowerCount  int32     `json:"follower_count"`
+	FollowingCount int32     `json:"following_count"`
+	// URLs of each size, keyed by size name; null when there's no image
+	Avatar    map[string]string `json:"avatar"`
+	Header    map[string]string `json:"header"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Someone in the caller's block or mute list, and since when
+type ListedUser struct {
+	UserID uuid.UUID `json:"user_id"`
+	Since  time.Time `json:"since"`
+}
+
+// A reply somewhere below the chirp a thread was asked for. Depth 1 is a
+// direct reply.
+type ThreadReply struct {
+	Chirp
+	Depth int32 `json:"depth"`
+}
+
+// A chirp matching a search, with the matched words wrapped in <mark> in
+// the (HTML escaped) snippet
+type SearchResult struct {
+	Chirp
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// A tag's score from the last time trending was worked out
+type TrendingHashtag struct {
+	Tag        string    `json:"tag"`
+	Score      float64   `json:"score"`
+	ChirpCount int32     `json:"chirp_count"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// What a chirp said before an edit, and when
+type ChirpRevision struct {
+	ID         uuid.UUID `json:"id"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	SignedInAt time.Time `json:"signed_in_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+}
+
+type Subscription struct {
+	ID         uuid.UUID  `json:"id"`
+	Plan       string     `json:"plan"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	RenewsAt   *time.Time `json:"renews_at"`
+	CanceledAt *time.Time `json:"canceled_at"`
+	EndedAt    *time.Time `json:"ended_at"`
+}
+
+type BillingEvent struct {
+	ID             uuid.UUID `json:"id"`
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	Event          string    `json:"event"`
+	Plan           string    `json:"plan"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type WebhookEvent struct {
+	Provider    string          `json:"provider"`
+	EventID     string          `json:"event_id"`
+	EventType   string          `json:"event_type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	LastError   string          `json:"last_error,omitempty"`
+	Attempts    int32           `json:"attempts"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	ProcessedAt *time.Time      `json:"processed_at"`
 }
 
 type RereshToken struct {
@@ -1,10 +1,18 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"net/http"
 	"sync/atomic"
+	"time"
 
+	auth "github.com/avgra3/chirpy/internal/auth"
+	"github.com/avgra3/chirpy/internal/blobstore"
 	"github.com/avgra3/chirpy/internal/database"
+	"github.com/avgra3/chirpy/internal/entitlements"
+	"github.com/avgra3/chirpy/internal/mailer"
 )
 
 // Types
@@ -13,12 +21,34 @@ type apiConfig struct {
 	fileserverHits atomic.Int32
 	// For connecting to our database
 	dbQuerries *database.Queries
+	// Raw connection, used when queries need to run in a transaction
+	db *sql.DB
 	// Platform
 	platform string
-	// JWT Secret
-	jwtSecret string
+	// Keys used to sign and verify access tokens
+	keyRing *auth.KeyRing
+	// Hashes and checks user passwords
+	passwordHasher *auth.PasswordHasher
 	// Polka API Key
 	polkaKey string
+	// Shared secret for Polka's HMAC signatures. Unsigned webhooks are
+	// accepted while this is empty.
+	polkaWebhookSecret string
+	// Sends emails to users
+	mailer mailer.Mailer
+	// What each tier (free or Chirpy Red) may do
+	entitlements *entitlements.Table
+	// How long after posting a chirp can still be edited
+	chirpEditWindow time.Duration
+	// How long a subscription runs when Polka doesn't say when it renews
+	subscriptionPeriod time.Duration
+	// Authors with at least this many followers aren't fanned out; their
+	// chirps are merged into timelines when they're read
+	fanoutMaxFollowers int32
+	// Nudges the fan-out worker when a chirp is queued
+	fanoutWake chan struct{}
+	// Where uploaded images are kept
+	blobs blobstore.Store
 }
 
 // Middleware
@@ -30,3 +60,59 @@ func (cfg *apiConfig) middlewareMetricsInt(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+type contextKey string
+
+const principalContextKey contextKey = "principal"
+
+// Checks the access token and that it carries every scope the route needs,
+// then hands the caller to the handler through the request context.
+func (cfg *apiConfig) requireAuth(next http.HandlerFunc, scopes ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accessToken, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			respondWithError(w, 401, "Bad access token")
+			return
+		}
+		principal, err := cfg.keyRing.ValidateJWT(accessToken)
+		if err != nil {
+			respondWithError(w, 401, "Bad access token")
+			return
+		}
+		for _, scope := range scopes {
+			if !principal.HasScope(scope) {
+				respondWithError(w, 403, fmt.Sprintf("Missing scope: %v", scope))
+				return
+			}
+		}
+		ctx := context.WithValue(r.Context(), principalContextKey, principal)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// For public routes that show more to signed-in callers. A request without
+// a token goes through anonymously; one with a bad token is turned away. A
+// token without users:read (such as a two-factor challenge token) doesn't
+// sign the caller in, so it also goes through anonymously.
+func (cfg *apiConfig) optionalAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next(w, r)
+			return
+		}
+		cfg.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+			if !requestPrincipal(r).HasScope(auth.ScopeUsersRead) {
+				ctx := context.WithValue(r.Context(), principalContextKey, auth.Principal{})
+				r = r.WithContext(ctx)
+			}
+			next(w, r)
+		})(w, r)
+	}
+}
+
+// The caller authenticated by requireAuth, or the zero Principal if the
+// request was let through anonymously
+func requestPrincipal(r *http.Request) auth.Principal {
+	principal, _ := r.Context().Value(principalContextKey).(auth.Principal)
+	return principal
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Notifications are written in the same transaction as whatever caused them:
+// a mention, a reply, a like. Nobody is notified about their own actions,
+// and the same action never notifies someone twice.
+
+func isMentionRune(r rune) bool {
+	return isHashtagRune(r) || strings.ContainsRune(".-+%@", r)
+}
+
+// The distinct @mentions in a chirp, lowercased and without the @, in the
+// order they first appear. A mention is either an email address
+// ("@ada@example.com") or a handle ("@ada").
+func extractMentions(body string) []string {
+	mentions := []string{}
+	seen := map[string]bool{}
+	runes := []rune(body)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && isMentionRune(runes[i-1])) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && isMentionRune(runes[end]) {
+			end++
+		}
+		// Punctuation ending a sentence isn't part of the mention
+		mention := strings.ToLower(strings.TrimRight(string(runes[i+1:end]), ".-"))
+		if validMention(mention) && !seen[mention] {
+			seen[mention] = true
+			mentions = append(mentions, mention)
+		}
+		i = end - 1
+	}
+	return mentions
+}
+
+func validMention(mention string) bool {
+	if strings.Contains(mention, "@") {
+		return validEmail(mention)
+	}
+	if mention == "" {
+		return false
+	}
+	for _, r := range mention {
+		if !isHashtagRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Notifies everyone a chirp mentions, by email or by handle
+func notifyMentions(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	emails := []string{}
+	handles := []string{}
+	for _, mention := range extractMentions(chirp.Body) {
+		if strings.Contains(mention, "@") {
+			emails = append(emails, mention)
+		} else {
+			handles = append(handles, mention)
+		}
+	}
+	if len(emails) == 0 && len(handles) == 0 {
+		return nil
+	}
+	return qtx.NotifyMentions(ctx, database.NotifyMentionsParams{
+		Emails:  emails,
+		Handles: handles,
+		ChirpID: chirp.ID,
+	})
+}
+
+// Same shape as a chirp cursor
+func encodeNotificationCursor(notification database.Notification) string {
+	data, _ := json.Marshal(chirpCursor{CreatedAt: notification.CreatedAt, ID: notification.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// The caller's notifications, newest first, and how many are unread
+func (cfg *apiConfig) listNotifications(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		UnreadCount   int64          `json:"unread_count"`
+		Notifications []Notification `json:"notifications"`
+	}
+	userID := requestPrincipal(r).UserID
+	query := r.URL.Query()
+	limit, err := pageSize(query)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	params := database.ListNotificationsParams{
+		UserID:     userID,
+		MaxResults: limit + 1,
+	}
+	if value := query.Get("unread"); value != "" {
+		params.UnreadOnly, err = strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(w, 400, "unread must be true or false")
+			return
+		}
+	}
+	if cursorParam := query.Get("cursor"); cursorParam != "" {
+		cursor, err := decodeChirpCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, 400, err.Error())
+			return
+		}
+		params.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	ctx := context.Background()
+	rows, err := cfg.dbQuerries.ListNotifications(ctx, params)
+	if err != nil {
+		log.Printf("ERROR: listing notifications for %v: %v", userID, err)
+		respondWithError(w, 500, "Unable to get notifications")
+		return
+	}
+	unread, err := cfg.dbQuerries.CountUnreadNotifications(ctx, userID)
+	if err != nil {
+		log.Printf("ERROR: counting notifications for %v: %v", userID, err)
+		respondWithError(w, 500, "Unable to get notifications")
+		return
+	}
+	if len(rows) > int(limit) {
+		rows = rows[:limit]
+		setNextPage(w, r, encodeNotificationCursor(rows[len(rows)-1]))
+	}
+
+	resp := response{UnreadCount: unread, Notifications: []Notification{}}
+	for _, row := range rows {
+		resp.Notifications = append(resp.Notifications, Notification{
+			ID:        row.ID,
+			Type:      row.Type,
+			ActorID:   row.ActorID,
+			ChirpID:   nullUUID(row.ChirpID),
+			CreatedAt: row.CreatedAt,
+			ReadAt:    nullTime(row.ReadAt),
+		})
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+// Marks the given notifications, or all of them, as read
+func (cfg *apiConfig) markNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		IDs []uuid.UUID `json:"ids"`
+		All bool        `json:"all"`
+	}
+	type response struct {
+		Marked      int64 `json:"marked"`
+		UnreadCount int64 `json:"unread_count"`
+	}
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, 500, "couldn't read request")
+		return
+	}
+	params := parameters{}
+	err = json.Unmarshal(data, &params)
+	if err != nil {
+		respondWithError(w, 400, "couldn't unmarshal parameters")
+		return
+	}
+	if !params.All && len(params.IDs) == 0 {
+		respondWithError(w, 400, "Give the ids to mark as read, or all")
+		return
+	}
+	userID := requestPrincipal(r).UserID
+
+	ctx := context.Background()
+	var marked int64
+	if params.All {
+		marked, err = cfg.dbQuerries.MarkAllNotificationsRead(ctx, userID)
+	} else {
+		marked, err = cfg.dbQuerries.MarkNotificationsRead(ctx, database.MarkNotificationsReadParams{
+			UserID: userID,
+			Ids:    params.IDs,
+		})
+	}
+	if err != nil {
+		log.Printf("ERROR: marking notifications read for %v: %v", userID, err)
+		respondWithError(w, 500, "Unable to mark notifications read")
+		return
+	}
+	unread, err := cfg.dbQuerries.CountUnreadNotifications(ctx, userID)
+	if err != nil {
+		log.Printf("ERROR: counting notifications for %v: %v", userID, err)
+		respondWithError(w, 500, "Unable to mark notifications read")
+		return
+	}
+	respondWithJSON(w, 200, response{Marked: marked, UnreadCount: unread})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Lists are paged by keyset rather than offset: the cursor is the sort key
+// of the last row sent, and the next page starts just after it. Clients get
+// the cursor base64 encoded and should treat it as opaque.
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+type chirpCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func encodeChirpCursor(chirp database.Chirp) string {
+	data, _ := json.Marshal(chirpCursor{CreatedAt: chirp.CreatedAt, ID: chirp.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeChirpCursor(cursor string) (chirpCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return chirpCursor{}, errors.New("malformed cursor")
+	}
+	decoded := chirpCursor{}
+	err = json.Unmarshal(data, &decoded)
+	if err != nil || decoded.ID == uuid.Nil || decoded.CreatedAt.IsZero() {
+		return chirpCursor{}, errors.New("malformed cursor")
+	}
+	return decoded, nil
+}
+
+// The limit query parameter, defaulting when it's missing
+func pageSize(query url.Values) (int32, error) {
+	value := query.Get("limit")
+	if value == "" {
+		return defaultPageSize, nil
+	}
+	limit, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %v", maxPageSize)
+	}
+	return int32(limit), nil
+}
+
+// An optional RFC 3339 time query parameter. Timestamps are stored in UTC.
+func timeParam(query url.Values, name string) (sql.NullTime, error) {
+	value := query.Get(name)
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("%v must be an RFC 3339 time", name)
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
+
+// author_id can be repeated, or a comma separated list
+func authorIDsParam(query url.Values) ([]uuid.UUID, error) {
+	authorIDs := []uuid.UUID{}
+	for _, value := range query["author_id"] {
+		for _, authorIDParam := range strings.Split(value, ",") {
+			authorUUID, err := uuid.Parse(strings.TrimSpace(authorIDParam))
+			if err != nil {
+				return nil, errors.New("Unable to parse author_id")
+			}
+			authorIDs = append(authorIDs, authorUUID)
+		}
+	}
+	return authorIDs, nil
+}
+
+// Points the client at the next page: the same request with the new cursor
+func setNextPage(w http.ResponseWriter, r *http.Request, cursor string) {
+	query := r.URL.Query()
+	query.Set("cursor", cursor)
+	next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Set("X-Next-Cursor", cursor)
+	w.Header().Set("Link", fmt.Sprintf(`<%v>; rel="next"`, next.String()))
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	auth "github.com/avgra3/chirpy/internal/auth"
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/avgra3/chirpy/internal/mailer"
+)
+
+// Emails a one-time reset token. Always answers 202 so the endpoint can't be
+// used to find out which emails have accounts.
+func (cfg *apiConfig) requestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, 500, "couldn't read request")
+		return
+	}
+	params := parameters{}
+	err = json.Unmarshal(data, &params)
+	if err != nil {
+		respondWithError(w, 400, "couldn't unmarshal parameters")
+		return
+	}
+	if strings.TrimSpace(params.Email) == "" {
+		respondWithError(w, 400, "entered email was invalid (empty string)")
+		return
+	}
+
+	ctx := context.Background()
+	user, err := cfg.dbQuerries.UserLogin(ctx, params.Email)
+	if err != nil {
+		w.WriteHeader(202)
+		return
+	}
+	// Failures past this point are only logged: answering differently for a
+	// real account would give it away
+	err = cfg.sendPasswordReset(ctx, user)
+	if err != nil {
+		log.Printf("ERROR: sending password reset to %v: %v", user.ID, err)
+	}
+	w.WriteHeader(202)
+}
+
+// Issues a new reset token, invalidating earlier ones, and emails it
+func (cfg *apiConfig) sendPasswordReset(ctx context.Context, user database.User) error {
+	token, tokenHash, err := auth.MakeOneTimeToken()
+	if err != nil {
+		return err
+	}
+	// Only the newest reset email should work
+	err = cfg.dbQuerries.InvalidatePasswordResets(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	_, err = cfg.dbQuerries.CreatePasswordReset(ctx, database.CreatePasswordResetParams{
+		TokenHash: tokenHash,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		return err
+	}
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your Chirpy account.\n\n"+
+			"To choose a new password, send this token to POST /api/password-reset/confirm within the next hour:\n\n"+
+			"%v\n\nIf this wasn't you, you can ignore this email.\n", token),
+	})
+}
+
+// Redeems a reset token for a new password. Every session is logged out,
+// since whoever had the old password may still be signed in.
+func (cfg *apiConfig) confirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, 500, "couldn't read request")
+		return
+	}
+	params := parameters{}
+	err = json.Unmarshal(data, &params)
+	if err != nil {
+		respondWithError(w, 400, "couldn't unmarshal parameters")
+		return
+	}
+	if strings.TrimSpace(params.Password) == "" {
+		respondWithError(w, 400, "entered password was invalid (empty string)")
+		return
+	}
+	hashedPassword, err := cfg.passwordHasher.Hash(params.Password)
+	if err != nil {
+		respondWithError(w, 500, "unable to hash password")
+		return
+	}
+
+	ctx := context.Background()
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, 500, "Unable to reset password")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	// Marks the token used in the same statement that checks it
+	userID, err := qtx.UsePasswordReset(ctx, auth.HashOneTimeToken(params.Token))
+	if err == sql.ErrNoRows {
+		respondWithError(w, 400, "Invalid or expired token")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: using password reset: %v", err)
+		respondWithError(w, 500, "Unable to reset password")
+		return
+	}
+	err = qtx.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+		HashedPassword: hashedPassword,
+		ID:             userID,
+	})
+	if err != nil {
+		log.Printf("ERROR: updating password: %v", err)
+		respondWithError(w, 500, "Unable to reset password")
+		return
+	}
+	_, err = qtx.RevokeAllSessions(ctx, userID)
+	if err != nil {
+		log.Printf("ERROR: revoking sessions: %v", err)
+		respondWithError(w, 500, "Unable to reset password")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Unable to reset password")
+		return
+	}
+	w.WriteHeader(204)
+}
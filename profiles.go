@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Profiles are public, so they're built from the users row field by field
+// and never carry the email address or password hash. Handles are how people
+// @mention each other; they're unique ignoring case but keep the case they
+// were chosen with.
+
+const (
+	minHandleLength      = 3
+	maxHandleLength      = 30
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxLocationLength    = 30
+	maxWebsiteLength     = 100
+)
+
+// Handles that would clash with routes under /api/users/
+var reservedHandles = map[string]bool{
+	"me": true,
+}
+
+// ASCII letters, digits and underscores, so comparing them ignoring case is
+// the same in Go and Postgres
+func validHandle(handle string) bool {
+	if len(handle) < minHandleLength || len(handle) > maxHandleLength {
+		return false
+	}
+	if reservedHandles[strings.ToLower(handle)] {
+		return false
+	}
+	for _, r := range handle {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// An http or https address, or empty to clear it
+func validWebsite(website string) bool {
+	if website == "" {
+		return true
+	}
+	parsed, err := url.Parse(website)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func profileResponse(user database.User) Profile {
+	profile := Profile{
+		ID:             user.ID,
+		DisplayName:    user.DisplayName,
+		Bio:            user.Bio,
+		Location:       user.Location,
+		Website:        user.Website,
+		FollowerCount:  user.FollowerCount,
+		FollowingCount: user.FollowingCount,
+		Avatar:         avatarImage.urls(user),
+		Header:         headerImage.urls(user),
+		CreatedAt:      user.CreatedAt,
+	}
+	if user.Handle.Valid {
+		profile.Handle = &user.Handle.String
+	}
+	return profile
+}
+
+// A user's public profile, looked up by ID or by handle
+func (cfg *apiConfig) getUserProfile(w http.ResponseWriter, r *http.Request) {
+	ref := r.PathValue("user")
+	ctx := context.Background()
+	var user database.User
+	var err error
+	if userID, parseErr := uuid.Parse(ref); parseErr == nil {
+		user, err = cfg.dbQuerries.GetUserById(ctx, userID)
+	} else if validHandle(ref) {
+		user, err = cfg.dbQuerries.GetUserByHandle(ctx, ref)
+	} else {
+		err = sql.ErrNoRows
+	}
+	if err == sql.ErrNoRows {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: loading user %q: %v", ref, err)
+		respondWithError(w, 500, "Unable to get profile")
+		return
+	}
+	respondWithJSON(w, 200, profileResponse(user))
+}
+
+// Changes only the profile fields given; an empty string clears any of them
+// but the handle
+func (cfg *apiConfig) updateProfile(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Handle      *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		Location    *string `json:"location"`
+		Website     *string `json:"website"`
+	}
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, 500, "couldn't read request")
+		return
+	}
+	params := parameters{}
+	err = json.Unmarshal(data, &params)
+	if err != nil {
+		respondWithError(w, 400, "couldn't unmarshal parameters")
+		return
+	}
+
+	update := database.UpdateProfileParams{ID: requestPrincipal(r).UserID}
+	if params.Handle != nil {
+		handle := strings.TrimPrefix(*params.Handle, "@")
+		if !validHandle(handle) {
+			message := fmt.Sprintf("Handles are %v to %v letters, digits or underscores", minHandleLength, maxHandleLength)
+			respondWithError(w, 400, message)
+			return
+		}
+		update.Handle = sql.NullString{String: handle, Valid: true}
+	}
+	fields := []struct {
+		name      string
+		value     *string
+		maxLength int
+		column    *sql.NullString
+	}{
+		{"display_name", params.DisplayName, maxDisplayNameLength, &update.DisplayName},
+		{"bio", params.Bio, maxBioLength, &update.Bio},
+		{"location", params.Location, maxLocationLength, &update.Location},
+		{"website", params.Website, maxWebsiteLength, &update.Website},
+	}
+	for _, field := range fields {
+		if field.value == nil {
+			continue
+		}
+		value := strings.TrimSpace(*field.value)
+		if utf8.RuneCountInString(value) > field.maxLength {
+			message := fmt.Sprintf("%v is too long (limit is %v characters)", field.name, field.maxLength)
+			respondWithError(w, 400, message)
+			return
+		}
+		*field.column = sql.NullString{String: value, Valid: true}
+	}
+	if update.Website.Valid && !validWebsite(update.Website.String) {
+		respondWithError(w, 400, "website must be an http or https address")
+		return
+	}
+
+	user, err := cfg.dbQuerries.UpdateProfile(context.Background(), update)
+	if isUniqueViolation(err) {
+		respondWithError(w, 409, "That handle is taken")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: updating profile of %v: %v", update.ID, err)
+		respondWithError(w, 500, "Unable to update profile")
+		return
+	}
+	respondWithJSON(w, 200, profileResponse(user))
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// A rechirp is a chirp with no body of its own that points at the original
+// through rechirp_of; a quote chirp has a body and points through quote_of.
+// The original keeps a count of each. Rechirps always point at an original,
+// never at another rechirp.
+
+// The chirp a rechirp stands in for, or the chirp itself
+func originalChirpID(ctx context.Context, q *database.Queries, chirpID uuid.UUID) (uuid.UUID, error) {
+	chirp, err := q.GetChirpByChirpID(ctx, chirpID)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	if chirp.DeletedAt.Valid {
+		return uuid.UUID{}, sql.ErrNoRows
+	}
+	if chirp.RechirpOf.Valid {
+		return chirp.RechirpOf.UUID, nil
+	}
+	return chirp.ID, nil
+}
+
+// Takes a chirp off its original's counts before it's deleted or tombstoned
+func releaseOriginal(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	if chirp.RechirpOf.Valid {
+		return qtx.DecrementRechirpCount(ctx, chirp.RechirpOf.UUID)
+	}
+	if chirp.QuoteOf.Valid {
+		return qtx.DecrementQuoteCount(ctx, chirp.QuoteOf.UUID)
+	}
+	return nil
+}
+
+// Fills in the chirp each rechirp or quote points at. Only one level is
+// embedded: a quoted quote shows its own quote_of but not what it quotes.
+func (cfg *apiConfig) embedOriginals(ctx context.Context, chirps []*Chirp) error {
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if chirp.RechirpOf != nil {
+			ids = append(ids, *chirp.RechirpOf)
+		} else if chirp.QuoteOf != nil {
+			ids = append(ids, *chirp.QuoteOf)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	originals, err := cfg.dbQuerries.GetChirpsByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byID := map[uuid.UUID]database.Chirp{}
+	for _, original := range originals {
+		byID[original.ID] = original
+	}
+	for _, chirp := range chirps {
+		id := chirp.RechirpOf
+		if id == nil {
+			id = chirp.QuoteOf
+		}
+		if id == nil {
+			continue
+		}
+		if original, ok := byID[*id]; ok {
+			resp := chirpResponse(original)
+			chirp.Original = &resp
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) rechirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad chirp ID")
+		return
+	}
+	userID := requestPrincipal(r).UserID
+	ctx := context.Background()
+	author, err := cfg.dbQuerries.GetUserById(ctx, userID)
+	if err != nil {
+		respondWithError(w, 401, "User does not exist")
+		return
+	}
+	if !author.EmailVerifiedAt.Valid {
+		respondWithError(w, 403, "Verify your email before posting chirps")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, 500, "Unable to rechirp")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	originalID, err := originalChirpID(ctx, qtx, chirpID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: loading chirp %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to rechirp")
+		return
+	}
+	blocked, err := isBlockedFromChirp(ctx, qtx, userID, originalID)
+	if err != nil {
+		log.Printf("ERROR: checking blocks on %v: %v", originalID, err)
+		respondWithError(w, 500, "Unable to rechirp")
+		return
+	}
+	if blocked {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	// Also locks the original, so it can't be deleted out from under us
+	updated, err := qtx.IncrementRechirpCount(ctx, originalID)
+	if err != nil {
+		log.Printf("ERROR: counting rechirp of %v: %v", originalID, err)
+		respondWithError(w, 500, "Unable to rechirp")
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	rechirp, err := qtx.CreateRechirp(ctx, database.CreateRechirpParams{
+		UserID:    userID,
+		RechirpOf: uuid.NullUUID{UUID: originalID, Valid: true},
+	})
+	if err == sql.ErrNoRows {
+		respondWithError(w, 409, "You've already rechirped this chirp")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: rechirping %v: %v", originalID, err)
+		respondWithError(w, 500, "Unable to rechirp")
+		return
+	}
+	err = cfg.addToTimelines(ctx, qtx, rechirp, author)
+	if err != nil {
+		log.Printf("ERROR: queueing rechirp %v for timelines: %v", rechirp.ID, err)
+		respondWithError(w, 500, "Unable to rechirp")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Unable to rechirp")
+		return
+	}
+	cfg.wakeTimelineFanout()
+
+	resp := chirpResponse(rechirp)
+	err = cfg.prepareChirps(ctx, r, []*Chirp{&resp})
+	if err != nil {
+		log.Printf("ERROR: loading original of %v: %v", rechirp.ID, err)
+	}
+	respondWithJSON(w, 201, resp)
+}
+
+func (cfg *apiConfig) undoRechirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad chirp ID")
+		return
+	}
+	userID := requestPrincipal(r).UserID
+
+	ctx := context.Background()
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, 500, "Unable to undo rechirp")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	// Works with the original's ID or the rechirp's
+	originalID := chirpID
+	chirp, err := qtx.GetChirpByChirpID(ctx, chirpID)
+	if err == nil && chirp.RechirpOf.Valid {
+		originalID = chirp.RechirpOf.UUID
+	} else if err != nil && err != sql.ErrNoRows {
+		log.Printf("ERROR: loading chirp %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to undo rechirp")
+		return
+	}
+	deleted, err := qtx.DeleteRechirp(ctx, database.DeleteRechirpParams{
+		UserID:    userID,
+		RechirpOf: uuid.NullUUID{UUID: originalID, Valid: true},
+	})
+	if err != nil {
+		log.Printf("ERROR: undoing rechirp of %v: %v", originalID, err)
+		respondWithError(w, 500, "Unable to undo rechirp")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "You haven't rechirped this chirp")
+		return
+	}
+	err = qtx.DecrementRechirpCount(ctx, originalID)
+	if err != nil {
+		log.Printf("ERROR: uncounting rechirp of %v: %v", originalID, err)
+		respondWithError(w, 500, "Unable to undo rechirp")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Unable to undo rechirp")
+		return
+	}
+	w.WriteHeader(204)
+}
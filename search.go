@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Search results are ordered by rank, so their cursor is (rank, id) rather
+// than the (created_at, id) used when listing chirps
+type searchCursor struct {
+	Rank float32   `json:"r"`
+	ID   uuid.UUID `json:"id"`
+}
+
+func encodeSearchCursor(row database.SearchChirpsRow) string {
+	data, _ := json.Marshal(searchCursor{Rank: row.Rank, ID: row.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSearchCursor(cursor string) (searchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return searchCursor{}, errors.New("malformed cursor")
+	}
+	decoded := searchCursor{}
+	err = json.Unmarshal(data, &decoded)
+	if err != nil || decoded.ID == uuid.Nil {
+		return searchCursor{}, errors.New("malformed cursor")
+	}
+	return decoded, nil
+}
+
+// Turns what the user typed into a to_tsquery expression. Every term must
+// match: "quoted words" are a phrase, and a trailing * makes a prefix search.
+// Only letters and digits make it through, so the user can't inject tsquery
+// operators of their own.
+func parseSearchQuery(q string) (string, error) {
+	terms := []string{}
+	addTerm := func(text string, prefix bool) {
+		words := strings.FieldsFunc(text, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) == 0 {
+			return
+		}
+		if prefix {
+			words[len(words)-1] += ":*"
+		}
+		term := strings.Join(words, " <-> ")
+		if len(words) > 1 {
+			term = "(" + term + ")"
+		}
+		terms = append(terms, term)
+	}
+
+	rest := strings.TrimSpace(q)
+	for rest != "" {
+		if rest[0] == '"' {
+			// An unclosed quote runs to the end
+			phrase, after, _ := strings.Cut(rest[1:], `"`)
+			addTerm(phrase, false)
+			rest = strings.TrimSpace(after)
+			continue
+		}
+		end := strings.IndexFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+		if end < 0 {
+			end = len(rest)
+		}
+		word, after := rest[:end], rest[end:]
+		addTerm(word, strings.HasSuffix(word, "*"))
+		rest = strings.TrimSpace(after)
+	}
+	if len(terms) == 0 {
+		return "", errors.New("q must contain at least one word")
+	}
+	return strings.Join(terms, " & "), nil
+}
+
+func (cfg *apiConfig) searchChirps(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	tsQuery, err := parseSearchQuery(query.Get("q"))
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	limit, err := pageSize(query)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	authorIDs, err := authorIDsParam(query)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	since, err := timeParam(query, "since")
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	until, err := timeParam(query, "until")
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+
+	params := database.SearchChirpsParams{
+		Query:      tsQuery,
+		AuthorIds:  authorIDs,
+		Since:      since,
+		Until:      until,
+		ViewerID:   requestPrincipal(r).UserID,
+		MaxResults: limit + 1,
+	}
+	if cursorParam := query.Get("cursor"); cursorParam != "" {
+		cursor, err := decodeSearchCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, 400, err.Error())
+			return
+		}
+		params.AfterRank = sql.NullFloat64{Float64: float64(cursor.Rank), Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	ctx := context.Background()
+	rows, err := cfg.dbQuerries.SearchChirps(ctx, params)
+	if err != nil {
+		log.Printf("ERROR: searching chirps for %q: %v", tsQuery, err)
+		respondWithError(w, 500, "There was a problem searching chirps.")
+		return
+	}
+	if len(rows) > int(limit) {
+		rows = rows[:limit]
+		setNextPage(w, r, encodeSearchCursor(rows[len(rows)-1]))
+	}
+
+	results := []SearchResult{}
+	for _, row := range rows {
+		results = append(results, SearchResult{
+			// Search only matches live chirps, so there's no deleted_at to copy
+			Chirp: chirpResponse(database.Chirp{
+				ID:           row.ID,
+				CreatedAt:    row.CreatedAt,
+				UpdatedAt:    row.UpdatedAt,
+				Body:         row.Body,
+				UserID:       row.UserID,
+				InReplyTo:    row.InReplyTo,
+				ReplyCount:   row.ReplyCount,
+				RechirpOf:    row.RechirpOf,
+				QuoteOf:      row.QuoteOf,
+				RechirpCount: row.RechirpCount,
+				QuoteCount:   row.QuoteCount,
+				LikeCount:    row.LikeCount,
+			}),
+			Rank:    row.Rank,
+			Snippet: row.Snippet,
+		})
+	}
+	embedded := []*Chirp{}
+	for i := range results {
+		embedded = append(embedded, &results[i].Chirp)
+	}
+	err = cfg.prepareChirps(ctx, r, embedded)
+	if err != nil {
+		log.Printf("ERROR: loading quoted chirps: %v", err)
+		respondWithError(w, 500, "There was a problem searching chirps.")
+		return
+	}
+	respondWithJSON(w, 200, results)
+}
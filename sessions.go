@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// A session is every refresh token handed out from a single login. Rotation
+// keeps the family id, so it doubles as a stable session id.
+
+func (cfg *apiConfig) listSessions(w http.ResponseWriter, r *http.Request) {
+	userID := requestPrincipal(r).UserID
+
+	ctx := context.Background()
+	rows, err := cfg.dbQuerries.GetActiveSessionsByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("ERROR: listing sessions: %v", err)
+		respondWithError(w, 500, "Unable to list sessions")
+		return
+	}
+	sessions := []Session{}
+	for _, row := range rows {
+		sessions = append(sessions, Session{
+			ID:         row.FamilyID,
+			SignedInAt: row.SignedInAt,
+			LastUsedAt: row.LastUsedAt,
+			ExpiresAt:  row.ExpiresAt,
+			UserAgent:  row.UserAgent,
+			IPAddress:  row.IpAddress,
+		})
+	}
+	respondWithJSON(w, 200, sessions)
+}
+
+func (cfg *apiConfig) revokeSession(w http.ResponseWriter, r *http.Request) {
+	userID := requestPrincipal(r).UserID
+	sessionID, err := uuid.Parse(r.PathValue("sessionID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad session ID")
+		return
+	}
+
+	// Scoped to the caller, so someone else's session looks like a missing one
+	ctx := context.Background()
+	revoked, err := cfg.dbQuerries.RevokeSession(ctx, database.RevokeSessionParams{
+		FamilyID: sessionID,
+		UserID:   userID,
+	})
+	if err != nil {
+		log.Printf("ERROR: revoking session %v: %v", sessionID, err)
+		respondWithError(w, 500, "Unable to revoke session")
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, 404, "Session not found")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) revokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID := requestPrincipal(r).UserID
+
+	ctx := context.Background()
+	_, err := cfg.dbQuerries.RevokeAllSessions(ctx, userID)
+	if err != nil {
+		log.Printf("ERROR: revoking sessions for %v: %v", userID, err)
+		respondWithError(w, 500, "Unable to revoke sessions")
+		return
+	}
+	w.WriteHeader(204)
+}
@@ -0,0 +1,59 @@
+-- name: CreateBlock :execrows
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBlock :execrows
+DELETE FROM blocks
+WHERE blocker_id = $1
+AND blocked_id = $2;
+
+-- name: CreateMute :execrows
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteMute :execrows
+DELETE FROM mutes
+WHERE muter_id = $1
+AND muted_id = $2;
+
+-- name: BlockExists :one
+-- Whether either user has blocked the other
+SELECT EXISTS (
+	SELECT 1
+	FROM blocks
+	WHERE (blocker_id = sqlc.arg(user_id) AND blocked_id = sqlc.arg(other_id))
+	OR (blocker_id = sqlc.arg(other_id) AND blocked_id = sqlc.arg(user_id))
+);
+
+-- name: IsBlockedFromChirp :one
+-- Whether the user and the chirp's author have blocked each other either way
+SELECT EXISTS (
+	SELECT 1
+	FROM chirps
+	JOIN blocks ON (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg(user_id))
+	OR (blocks.blocker_id = sqlc.arg(user_id) AND blocks.blocked_id = chirps.user_id)
+	WHERE chirps.id = sqlc.arg(chirp_id)
+);
+
+-- name: ListHiddenAuthorIDs :many
+SELECT author_id
+FROM hidden_authors
+WHERE viewer_id = $1;
+
+-- name: ListBlocks :many
+SELECT blocked_id AS user_id, created_at
+FROM blocks
+WHERE blocker_id = sqlc.arg(user_id)
+AND (sqlc.narg(after_created_at)::TIMESTAMP IS NULL OR (created_at, blocked_id) < (sqlc.narg(after_created_at), sqlc.narg(after_id)::UUID))
+ORDER BY created_at DESC, blocked_id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: ListMutes :many
+SELECT muted_id AS user_id, created_at
+FROM mutes
+WHERE muter_id = sqlc.arg(user_id)
+AND (sqlc.narg(after_created_at)::TIMESTAMP IS NULL OR (created_at, muted_id) < (sqlc.narg(after_created_at), sqlc.narg(after_id)::UUID))
+ORDER BY created_at DESC, muted_id DESC
+LIMIT sqlc.arg(max_results);
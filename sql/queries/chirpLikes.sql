@@ -0,0 +1,43 @@
+-- name: IncrementLikeCount :execrows
+UPDATE chirps
+SET like_count = like_count + 1
+WHERE id = $1
+AND deleted_at IS NULL
+AND rechirp_of IS NULL;
+
+-- name: DecrementLikeCount :exec
+UPDATE chirps
+SET like_count = like_count - 1
+WHERE id = $1
+AND like_count > 0;
+
+-- name: CreateChirpLike :execrows
+INSERT INTO chirp_likes (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpLike :execrows
+DELETE FROM chirp_likes
+WHERE user_id = $1
+AND chirp_id = $2;
+
+-- name: DeleteChirpLikes :exec
+DELETE FROM chirp_likes
+WHERE chirp_id = $1;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id
+FROM chirp_likes
+WHERE user_id = sqlc.arg(user_id)
+AND chirp_id = ANY(sqlc.arg(chirp_ids)::UUID[]);
+
+-- name: ListLikedChirps :many
+SELECT sqlc.embed(chirps), chirp_likes.created_at AS liked_at
+FROM chirp_likes
+JOIN chirps ON chirps.id = chirp_likes.chirp_id
+WHERE chirp_likes.user_id = sqlc.arg(user_id)
+AND chirps.deleted_at IS NULL
+AND (sqlc.narg(after_liked_at)::TIMESTAMP IS NULL OR (chirp_likes.created_at, chirp_likes.chirp_id) < (sqlc.narg(after_liked_at), sqlc.narg(after_id)::UUID))
+AND NOT EXISTS (SELECT 1 FROM hidden_authors WHERE hidden_authors.viewer_id = sqlc.arg(viewer_id) AND hidden_authors.author_id = chirps.user_id)
+ORDER BY chirp_likes.created_at DESC, chirp_likes.chirp_id DESC
+LIMIT sqlc.arg(max_results);
@@ -0,0 +1,27 @@
+-- name: GetChirpForEdit :one
+SELECT *, created_at > NOW() - (sqlc.arg(edit_window_seconds)::INT * INTERVAL '1 second') AS editable
+FROM chirps
+WHERE id = sqlc.arg(id)
+FOR UPDATE;
+
+-- name: CreateChirpRevision :exec
+INSERT INTO chirp_revisions (id, chirp_id, body, created_at, replaced_at)
+VALUES
+(GEN_RANDOM_UUID(), $1, $2, $3, NOW());
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetChirpRevisions :many
+SELECT *
+FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY replaced_at DESC;
+
+-- name: DeleteChirpRevisions :exec
+DELETE FROM chirp_revisions
+WHERE chirp_id = $1;
@@ -0,0 +1,62 @@
+-- name: GetChirpForDelete :one
+SELECT *
+FROM chirps
+WHERE id = $1
+FOR UPDATE;
+
+-- name: IncrementReplyCount :execrows
+UPDATE chirps
+SET reply_count = reply_count + 1
+WHERE id = $1
+AND deleted_at IS NULL
+AND rechirp_of IS NULL;
+
+-- name: DecrementReplyCount :one
+UPDATE chirps
+SET reply_count = reply_count - 1
+WHERE id = $1
+AND reply_count > 0
+RETURNING user_id, in_reply_to, reply_count, deleted_at;
+
+-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '',
+quote_of = NULL,
+rechirp_count = 0,
+like_count = 0,
+deleted_at = NOW(),
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+	SELECT parent.id, parent.created_at, parent.updated_at, parent.body, parent.user_id, parent.in_reply_to, parent.reply_count, parent.deleted_at, parent.rechirp_of, parent.quote_of, parent.rechirp_count, parent.quote_count, parent.like_count, 1 AS depth
+	FROM chirps AS child
+	JOIN chirps AS parent ON parent.id = child.in_reply_to
+	WHERE child.id = $1
+	UNION ALL
+	SELECT parent.id, parent.created_at, parent.updated_at, parent.body, parent.user_id, parent.in_reply_to, parent.reply_count, parent.deleted_at, parent.rechirp_of, parent.quote_of, parent.rechirp_count, parent.quote_count, parent.like_count, ancestors.depth + 1
+	FROM ancestors
+	JOIN chirps AS parent ON parent.id = ancestors.in_reply_to
+)
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, deleted_at, rechirp_of, quote_of, rechirp_count, quote_count, like_count
+FROM ancestors
+ORDER BY depth DESC;
+
+-- name: GetChirpReplies :many
+WITH RECURSIVE replies AS (
+	SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, deleted_at, rechirp_of, quote_of, rechirp_count, quote_count, like_count, 1 AS depth,
+	ARRAY[TO_CHAR(created_at, 'YYYYMMDDHH24MISSUS') || id::TEXT] AS path
+	FROM chirps
+	WHERE chirps.in_reply_to = sqlc.arg(id)
+	UNION ALL
+	SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.reply_count, chirps.deleted_at, chirps.rechirp_of, chirps.quote_of, chirps.rechirp_count, chirps.quote_count, chirps.like_count, replies.depth + 1,
+	replies.path || (TO_CHAR(chirps.created_at, 'YYYYMMDDHH24MISSUS') || chirps.id::TEXT)
+	FROM replies
+	JOIN chirps ON chirps.in_reply_to = replies.id
+)
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, deleted_at, rechirp_of, quote_of, rechirp_count, quote_count, like_count, depth::INT AS depth, path::TEXT[] AS path
+FROM replies
+WHERE CARDINALITY(sqlc.arg(after_path)::TEXT[]) = 0 OR path > sqlc.arg(after_path)::TEXT[]
+ORDER BY path
+LIMIT sqlc.arg(max_results);
@@ -0,0 +1,19 @@
+-- name: CreateEmailVerification :one
+INSERT INTO email_verifications (token_hash, created_at, user_id, email, expires_at, used_at)
+VALUES
+($1, NOW(), $2, $3, (NOW() + interval '24' hour), NULL)
+RETURNING *;
+
+-- name: UseEmailVerification :one
+UPDATE email_verifications
+SET used_at = NOW()
+WHERE token_hash = $1
+AND used_at IS NULL
+AND expires_at > NOW()
+RETURNING user_id, email;
+
+-- name: InvalidateEmailVerifications :exec
+UPDATE email_verifications
+SET used_at = NOW()
+WHERE user_id = $1
+AND used_at IS NULL;
@@ -0,0 +1,33 @@
+-- name: CreateFollow :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFollow :execrows
+DELETE FROM follows
+WHERE follower_id = $1
+AND followee_id = $2;
+
+-- name: AdjustFollowCounts :exec
+-- Both users' rows in one statement, so two people following each other at
+-- once lock them in the same order
+UPDATE users
+SET follower_count = follower_count + CASE WHEN id = sqlc.arg(followee_id)::UUID THEN sqlc.arg(delta)::INT ELSE 0 END,
+following_count = following_count + CASE WHEN id = sqlc.arg(follower_id)::UUID THEN sqlc.arg(delta)::INT ELSE 0 END
+WHERE id IN (sqlc.arg(follower_id)::UUID, sqlc.arg(followee_id)::UUID);
+
+-- name: ListFollowers :many
+SELECT follower_id AS user_id, created_at
+FROM follows
+WHERE followee_id = sqlc.arg(user_id)
+AND (sqlc.narg(after_created_at)::TIMESTAMP IS NULL OR (created_at, follower_id) < (sqlc.narg(after_created_at), sqlc.narg(after_id)::UUID))
+ORDER BY created_at DESC, follower_id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: ListFollowing :many
+SELECT followee_id AS user_id, created_at
+FROM follows
+WHERE follower_id = sqlc.arg(user_id)
+AND (sqlc.narg(after_created_at)::TIMESTAMP IS NULL OR (created_at, followee_id) < (sqlc.narg(after_created_at), sqlc.narg(after_id)::UUID))
+ORDER BY created_at DESC, followee_id DESC
+LIMIT sqlc.arg(max_results);
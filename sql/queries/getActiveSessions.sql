@@ -0,0 +1,12 @@
+-- name: GetActiveSessionsByUserID :many
+SELECT family_id,
+(SELECT MIN(first.created_at) FROM refresh_tokens first WHERE first.family_id = refresh_tokens.family_id)::TIMESTAMP AS signed_in_at,
+user_agent,
+ip_address,
+last_used_at,
+expires_at
+FROM refresh_tokens
+WHERE user_id = $1
+AND revoked_at IS NULL
+AND expires_at > NOW()
+ORDER BY last_used_at DESC;
@@ -1,4 +1,4 @@
 -- name: GetChirpByChirpID :one
 SELECT *
 FROM chirps
-WHERE user_id = $1;
+WHERE id = $1;
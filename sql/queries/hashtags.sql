@@ -0,0 +1,52 @@
+-- name: TagChirp :exec
+WITH tags AS (
+	INSERT INTO hashtags (tag)
+	SELECT UNNEST(sqlc.arg(tags)::TEXT[])
+	ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+	RETURNING id
+)
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id, created_at)
+SELECT sqlc.arg(chirp_id), tags.id, sqlc.arg(created_at)
+FROM tags
+ON CONFLICT DO NOTHING;
+
+-- name: UntagChirp :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+
+-- name: ListHashtagChirps :many
+SELECT sqlc.embed(chirps)
+FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE hashtags.tag = sqlc.arg(tag)
+AND chirps.deleted_at IS NULL
+AND (sqlc.narg(after_created_at)::TIMESTAMP IS NULL OR (chirp_hashtags.created_at, chirp_hashtags.chirp_id) < (sqlc.narg(after_created_at), sqlc.narg(after_id)::UUID))
+AND NOT EXISTS (SELECT 1 FROM hidden_authors WHERE hidden_authors.viewer_id = sqlc.arg(viewer_id) AND hidden_authors.author_id = chirps.user_id)
+ORDER BY chirp_hashtags.created_at DESC, chirp_hashtags.chirp_id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: ClearTrendingHashtags :exec
+DELETE FROM trending_hashtags;
+
+-- name: ComputeTrendingHashtags :exec
+-- Each use of a tag in the window scores 1, halving every half life
+INSERT INTO trending_hashtags (hashtag_id, score, chirp_count, computed_at)
+SELECT chirp_hashtags.hashtag_id,
+SUM(POWER(0.5, EXTRACT(EPOCH FROM NOW() - chirp_hashtags.created_at) / sqlc.arg(half_life_seconds)::INT))::FLOAT8 AS score,
+COUNT(*),
+NOW()
+FROM chirp_hashtags
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.created_at > NOW() - (sqlc.arg(window_seconds)::INT * INTERVAL '1 second')
+AND chirps.deleted_at IS NULL
+GROUP BY chirp_hashtags.hashtag_id
+ORDER BY score DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: ListTrendingHashtags :many
+SELECT hashtags.tag, trending_hashtags.score, trending_hashtags.chirp_count, trending_hashtags.computed_at
+FROM trending_hashtags
+JOIN hashtags ON hashtags.id = trending_hashtags.hashtag_id
+ORDER BY trending_hashtags.score DESC, hashtags.tag
+LIMIT $1;
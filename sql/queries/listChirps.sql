@@ -0,0 +1,23 @@
+-- name: ListChirpsOldestFirst :many
+SELECT *
+FROM chirps
+WHERE deleted_at IS NULL
+AND (CARDINALITY(sqlc.arg(author_ids)::UUID[]) = 0 OR user_id = ANY(sqlc.arg(author_ids)::UUID[]))
+AND (sqlc.narg(since)::TIMESTAMP IS NULL OR created_at >= sqlc.narg(since))
+AND (sqlc.narg(until)::TIMESTAMP IS NULL OR created_at < sqlc.narg(until))
+AND (sqlc.narg(after_created_at)::TIMESTAMP IS NULL OR (created_at, id) > (sqlc.narg(after_created_at), sqlc.narg(after_id)::UUID))
+AND NOT EXISTS (SELECT 1 FROM hidden_authors WHERE hidden_authors.viewer_id = sqlc.arg(viewer_id) AND hidden_authors.author_id = chirps.user_id)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(max_results);
+
+-- name: ListChirpsNewestFirst :many
+SELECT *
+FROM chirps
+WHERE deleted_at IS NULL
+AND (CARDINALITY(sqlc.arg(author_ids)::UUID[]) = 0 OR user_id = ANY(sqlc.arg(author_ids)::UUID[]))
+AND (sqlc.narg(since)::TIMESTAMP IS NULL OR created_at >= sqlc.narg(since))
+AND (sqlc.narg(until)::TIMESTAMP IS NULL OR created_at < sqlc.narg(until))
+AND (sqlc.narg(after_created_at)::TIMESTAMP IS NULL OR (created_at, id) < (sqlc.narg(after_created_at), sqlc.narg(after_id)::UUID))
+AND NOT EXISTS (SELECT 1 FROM hidden_authors WHERE hidden_authors.viewer_id = sqlc.arg(viewer_id) AND hidden_authors.author_id = chirps.user_id)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_results);
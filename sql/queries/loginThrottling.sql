@@ -0,0 +1,50 @@
+-- name: RecordFailedLogin :one
+UPDATE users
+SET failed_login_attempts = CASE
+	WHEN last_failed_login_at < NOW() - INTERVAL '1 hour' THEN 1
+	ELSE failed_login_attempts + 1
+END,
+last_failed_login_at = NOW()
+WHERE id = $1
+RETURNING failed_login_attempts;
+
+-- name: LockUser :exec
+UPDATE users
+SET locked_until = NOW() + (sqlc.arg(lock_seconds)::INT * INTERVAL '1 second')
+WHERE id = sqlc.arg(id);
+
+-- name: ResetFailedLogins :execrows
+UPDATE users
+SET failed_login_attempts = 0,
+last_failed_login_at = NULL,
+locked_until = NULL
+WHERE id = $1;
+
+-- name: GetUserLockoutSeconds :one
+SELECT CEIL(EXTRACT(EPOCH FROM (locked_until - NOW())))::INT AS retry_after
+FROM users
+WHERE id = $1
+AND locked_until > NOW();
+
+-- name: RecordFailedLoginForIP :one
+INSERT INTO login_ip_failures (ip_address, failed_attempts, last_failed_at, blocked_until)
+VALUES
+($1, 1, NOW(), NULL)
+ON CONFLICT (ip_address) DO UPDATE
+SET failed_attempts = CASE
+	WHEN login_ip_failures.last_failed_at < NOW() - INTERVAL '1 hour' THEN 1
+	ELSE login_ip_failures.failed_attempts + 1
+END,
+last_failed_at = NOW()
+RETURNING failed_attempts;
+
+-- name: BlockIP :exec
+UPDATE login_ip_failures
+SET blocked_until = NOW() + (sqlc.arg(block_seconds)::INT * INTERVAL '1 second')
+WHERE ip_address = sqlc.arg(ip_address);
+
+-- name: GetIPBlockSeconds :one
+SELECT CEIL(EXTRACT(EPOCH FROM (blocked_until - NOW())))::INT AS retry_after
+FROM login_ip_failures
+WHERE ip_address = $1
+AND blocked_until > NOW();
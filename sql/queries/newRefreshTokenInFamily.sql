@@ -0,0 +1,5 @@
+-- name: NewRefreshTokenInFamily :one
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, user_agent, ip_address, last_used_at)
+VALUES
+($1, NOW(), NOW(), $2, (NOW() + interval '60' day), NULL, $3, $4, $5, NOW())
+RETURNING *;
@@ -1,5 +1,5 @@
 -- name: NewRereshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at, user_agent, ip_address, last_used_at)
 VALUES
-($1, NOW(), NOW(), $2, (NOW() + interval '60' day), NULL)
+($1, NOW(), NOW(), $2, (NOW() + interval '60' day), NULL, $3, $4, NOW())
 RETURNING *;
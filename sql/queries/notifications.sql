@@ -0,0 +1,63 @@
+-- name: NotifyMentions :exec
+INSERT INTO notifications (user_id, actor_id, type, chirp_id)
+SELECT users.id, chirps.user_id, 'mention', chirps.id
+FROM chirps
+JOIN users ON LOWER(users.email) = ANY(sqlc.arg(emails)::TEXT[])
+OR LOWER(users.handle) = ANY(sqlc.arg(handles)::TEXT[])
+WHERE chirps.id = sqlc.arg(chirp_id)
+AND users.id <> chirps.user_id
+-- Nobody who has blocked the author
+AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = users.id AND blocks.blocked_id = chirps.user_id)
+ON CONFLICT DO NOTHING;
+
+-- name: NotifyReply :exec
+INSERT INTO notifications (user_id, actor_id, type, chirp_id)
+SELECT parent.user_id, reply.user_id, 'reply', reply.id
+FROM chirps AS reply
+JOIN chirps AS parent ON parent.id = reply.in_reply_to
+WHERE reply.id = $1
+AND parent.user_id <> reply.user_id
+ON CONFLICT DO NOTHING;
+
+-- name: NotifyLike :exec
+INSERT INTO notifications (user_id, actor_id, type, chirp_id)
+SELECT chirps.user_id, sqlc.arg(actor_id)::UUID, 'like', chirps.id
+FROM chirps
+WHERE chirps.id = sqlc.arg(chirp_id)
+AND chirps.user_id <> sqlc.arg(actor_id)::UUID
+ON CONFLICT DO NOTHING;
+
+-- name: ListNotifications :many
+SELECT *
+FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+AND (NOT sqlc.arg(unread_only)::BOOLEAN OR read_at IS NULL)
+AND (sqlc.narg(after_created_at)::TIMESTAMP IS NULL OR (created_at, id) < (sqlc.narg(after_created_at), sqlc.narg(after_id)::UUID))
+AND NOT EXISTS (SELECT 1 FROM hidden_authors WHERE hidden_authors.viewer_id = notifications.user_id AND hidden_authors.author_id = notifications.actor_id)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*)
+FROM notifications
+WHERE user_id = $1
+AND read_at IS NULL
+AND NOT EXISTS (SELECT 1 FROM hidden_authors WHERE hidden_authors.viewer_id = notifications.user_id AND hidden_authors.author_id = notifications.actor_id);
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = sqlc.arg(user_id)
+AND id = ANY(sqlc.arg(ids)::UUID[])
+AND read_at IS NULL;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1
+AND read_at IS NULL;
+
+-- name: NotifyFollow :exec
+INSERT INTO notifications (user_id, actor_id, type)
+VALUES ($1, $2, 'follow')
+ON CONFLICT DO NOTHING;
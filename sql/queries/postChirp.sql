@@ -1,5 +1,5 @@
 -- name: PostChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, quote_of)
 VALUES
-(GEN_RANDOM_UUID(), NOW(), NOW(), $1, $2)
+(GEN_RANDOM_UUID(), NOW(), NOW(), $1, $2, $3, $4)
 RETURNING *;
@@ -1,7 +0,0 @@
--- name: UpdateUserEmailPassword :one
-UPDATE users
-SET email = $1,
-hashed_password = $2,
-updated_at = NOW()
-WHERE id = $3
-RETURNING id, created_at, updated_at, email;
@@ -0,0 +1,30 @@
+-- name: GetUserByHandle :one
+SELECT *
+FROM users
+WHERE LOWER(handle) = LOWER(sqlc.arg(handle));
+
+-- name: UpdateProfile :one
+-- Only the fields given are changed
+UPDATE users
+SET handle = COALESCE(sqlc.narg(handle), handle),
+display_name = COALESCE(sqlc.narg(display_name), display_name),
+bio = COALESCE(sqlc.narg(bio), bio),
+location = COALESCE(sqlc.narg(location), location),
+website = COALESCE(sqlc.narg(website), website),
+updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: SetAvatarKey :one
+UPDATE users
+SET avatar_key = sqlc.narg(key),
+updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: SetHeaderKey :one
+UPDATE users
+SET header_key = sqlc.narg(key),
+updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,46 @@
+-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, rechirp_of)
+VALUES
+(GEN_RANDOM_UUID(), NOW(), NOW(), '', $1, $2)
+ON CONFLICT (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL DO NOTHING
+RETURNING *;
+
+-- name: DeleteRechirp :execrows
+DELETE FROM chirps
+WHERE user_id = $1
+AND rechirp_of = $2;
+
+-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps
+WHERE rechirp_of = $1;
+
+-- name: IncrementRechirpCount :execrows
+UPDATE chirps
+SET rechirp_count = rechirp_count + 1
+WHERE id = $1
+AND deleted_at IS NULL
+AND rechirp_of IS NULL;
+
+-- name: DecrementRechirpCount :exec
+UPDATE chirps
+SET rechirp_count = rechirp_count - 1
+WHERE id = $1
+AND rechirp_count > 0;
+
+-- name: IncrementQuoteCount :execrows
+UPDATE chirps
+SET quote_count = quote_count + 1
+WHERE id = $1
+AND deleted_at IS NULL
+AND rechirp_of IS NULL;
+
+-- name: DecrementQuoteCount :exec
+UPDATE chirps
+SET quote_count = quote_count - 1
+WHERE id = $1
+AND quote_count > 0;
+
+-- name: GetChirpsByIDs :many
+SELECT *
+FROM chirps
+WHERE id = ANY(sqlc.arg(ids)::UUID[]);
@@ -0,0 +1,17 @@
+-- name: SearchChirps :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, rechirp_of, quote_of, rechirp_count, quote_count, like_count, rank,
+TS_HEADLINE('english', REPLACE(REPLACE(REPLACE(body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), TO_TSQUERY('english', sqlc.arg(query)), 'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15')::TEXT AS snippet
+FROM (
+	SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.reply_count, chirps.rechirp_of, chirps.quote_of, chirps.rechirp_count, chirps.quote_count, chirps.like_count,
+	TS_RANK(chirps.search_vector, TO_TSQUERY('english', sqlc.arg(query)))::REAL AS rank
+	FROM chirps
+	WHERE chirps.deleted_at IS NULL
+	AND chirps.search_vector @@ TO_TSQUERY('english', sqlc.arg(query))
+	AND (CARDINALITY(sqlc.arg(author_ids)::UUID[]) = 0 OR chirps.user_id = ANY(sqlc.arg(author_ids)::UUID[]))
+	AND (sqlc.narg(since)::TIMESTAMP IS NULL OR chirps.created_at >= sqlc.narg(since))
+	AND (sqlc.narg(until)::TIMESTAMP IS NULL OR chirps.created_at < sqlc.narg(until))
+	AND NOT EXISTS (SELECT 1 FROM hidden_authors WHERE hidden_authors.viewer_id = sqlc.arg(viewer_id) AND hidden_authors.author_id = chirps.user_id)
+) AS matches
+WHERE (sqlc.narg(after_rank)::REAL IS NULL OR (rank, id) < (sqlc.narg(after_rank), sqlc.narg(after_id)::UUID))
+ORDER BY rank DESC, id DESC
+LIMIT sqlc.arg(max_results);
@@ -0,0 +1,4 @@
+-- name: SetUserChirpyRed :exec
+UPDATE users
+SET is_chirpy_red = $2
+WHERE id = $1;
@@ -0,0 +1,66 @@
+-- name: CreateSubscription :one
+INSERT INTO subscriptions (id, user_id, plan, status, started_at, renews_at, canceled_at, ended_at, created_at, updated_at)
+VALUES
+(GEN_RANDOM_UUID(), sqlc.arg(user_id), sqlc.arg(plan), 'active', NOW(), NOW() + (sqlc.arg(renews_in_seconds)::INT * INTERVAL '1 second'), NULL, NULL, NOW(), NOW())
+RETURNING *;
+
+-- name: GetCurrentSubscription :one
+SELECT *
+FROM subscriptions
+WHERE user_id = $1
+AND status IN ('active', 'canceled');
+
+-- name: GetLatestSubscription :one
+SELECT *
+FROM subscriptions
+WHERE user_id = $1
+ORDER BY started_at DESC
+LIMIT 1;
+
+-- name: RenewSubscription :one
+UPDATE subscriptions
+SET status = 'active',
+plan = sqlc.arg(plan),
+renews_at = NOW() + (sqlc.arg(renews_in_seconds)::INT * INTERVAL '1 second'),
+canceled_at = NULL,
+updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CancelSubscription :one
+UPDATE subscriptions
+SET status = 'canceled',
+canceled_at = NOW(),
+updated_at = NOW()
+WHERE user_id = $1
+AND status = 'active'
+RETURNING *;
+
+-- name: EndSubscription :one
+UPDATE subscriptions
+SET status = 'expired',
+ended_at = NOW(),
+updated_at = NOW()
+WHERE user_id = $1
+AND status IN ('active', 'canceled')
+RETURNING *;
+
+-- name: ExpireLapsedSubscriptions :many
+UPDATE subscriptions
+SET status = 'expired',
+ended_at = NOW(),
+updated_at = NOW()
+WHERE status IN ('active', 'canceled')
+AND renews_at <= NOW()
+RETURNING *;
+
+-- name: CreateSubscriptionEvent :exec
+INSERT INTO subscription_events (id, subscription_id, user_id, event_type, plan, webhook_event_id, created_at)
+VALUES
+(GEN_RANDOM_UUID(), $1, $2, $3, $4, $5, NOW());
+
+-- name: GetSubscriptionEventsByUserID :many
+SELECT *
+FROM subscription_events
+WHERE user_id = $1
+ORDER BY created_at DESC;
@@ -0,0 +1,138 @@
+-- name: AddOwnTimelineEntry :exec
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT user_id, id, user_id, created_at
+FROM chirps
+WHERE id = $1
+ON CONFLICT DO NOTHING;
+
+-- name: EnqueueTimelineFanout :exec
+INSERT INTO timeline_fanouts (chirp_id)
+VALUES ($1)
+ON CONFLICT DO NOTHING;
+
+-- name: AddTimelineMergedChirp :exec
+INSERT INTO timeline_merged_chirps (chirp_id, author_id, created_at)
+SELECT id, user_id, created_at
+FROM chirps
+WHERE id = $1
+ON CONFLICT DO NOTHING;
+
+-- name: ClaimTimelineFanouts :many
+SELECT chirp_id
+FROM timeline_fanouts
+ORDER BY created_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: FanOutChirp :execrows
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT follows.follower_id, chirps.id, chirps.user_id, chirps.created_at
+FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE chirps.id = $1
+AND chirps.deleted_at IS NULL
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteTimelineFanout :exec
+DELETE FROM timeline_fanouts
+WHERE chirp_id = $1;
+
+-- name: DeleteTimelineEntriesForChirp :exec
+DELETE FROM timeline_entries
+WHERE chirp_id = $1;
+
+-- name: DeleteTimelineEntriesByAuthor :exec
+DELETE FROM timeline_entries
+WHERE user_id = $1
+AND author_id = $2;
+
+-- name: BackfillTimeline :exec
+-- Someone newly followed: their latest chirps go straight in, except those
+-- merged in when the timeline is read
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT sqlc.arg(user_id)::UUID, chirps.id, chirps.user_id, chirps.created_at
+FROM chirps
+WHERE chirps.user_id = sqlc.arg(author_id)
+AND chirps.deleted_at IS NULL
+AND NOT EXISTS (SELECT 1 FROM timeline_merged_chirps WHERE timeline_merged_chirps.chirp_id = chirps.id)
+AND NOT EXISTS (SELECT 1 FROM hidden_authors WHERE hidden_authors.viewer_id = sqlc.arg(user_id) AND hidden_authors.author_id = chirps.user_id)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(per_author)
+ON CONFLICT DO NOTHING;
+
+-- name: ClearTimeline :exec
+DELETE FROM timeline_entries
+WHERE user_id = $1;
+
+-- name: RebuildTimeline :exec
+-- The latest chirps of the user and everyone they follow, leaving out other
+-- people's chirps that are merged in when the timeline is read
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT sqlc.arg(user_id)::UUID, recent.id, recent.user_id, recent.created_at
+FROM (
+	SELECT follows.followee_id AS author_id
+	FROM follows
+	WHERE follows.follower_id = sqlc.arg(user_id)
+	UNION ALL
+	SELECT sqlc.arg(user_id)::UUID
+) AS authors
+CROSS JOIN LATERAL (
+	SELECT chirps.id, chirps.user_id, chirps.created_at
+	FROM chirps
+	WHERE chirps.user_id = authors.author_id
+	AND chirps.deleted_at IS NULL
+	AND (chirps.user_id = sqlc.arg(user_id) OR NOT EXISTS (SELECT 1 FROM timeline_merged_chirps WHERE timeline_merged_chirps.chirp_id = chirps.id))
+	ORDER BY chirps.created_at DESC, chirps.id DESC
+	LIMIT sqlc.arg(per_author)
+) AS recent
+ON CONFLICT DO NOTHING;
+
+-- name: ListTimeline :many
+-- A page of the entries pushed to the user's timeline, merged with the
+-- newest chirps that were never pushed from the accounts they follow
+SELECT *
+FROM chirps
+WHERE chirps.id IN (
+	(
+		SELECT timeline_entries.chirp_id
+		FROM timeline_entries
+		WHERE timeline_entries.user_id = sqlc.arg(user_id)
+		AND (sqlc.narg(after_created_at)::TIMESTAMP IS NULL OR (timeline_entries.created_at, timeline_entries.chirp_id) < (sqlc.narg(after_created_at), sqlc.narg(after_id)::UUID))
+		ORDER BY timeline_entries.created_at DESC, timeline_entries.chirp_id DESC
+		LIMIT sqlc.arg(max_results)
+	)
+	UNION
+	(
+		SELECT recent.chirp_id
+		FROM follows
+		CROSS JOIN LATERAL (
+			SELECT timeline_merged_chirps.chirp_id
+			FROM timeline_merged_chirps
+			WHERE timeline_merged_chirps.author_id = follows.followee_id
+			AND (sqlc.narg(after_created_at)::TIMESTAMP IS NULL OR (timeline_merged_chirps.created_at, timeline_merged_chirps.chirp_id) < (sqlc.narg(after_created_at), sqlc.narg(after_id)::UUID))
+			ORDER BY timeline_merged_chirps.created_at DESC, timeline_merged_chirps.chirp_id DESC
+			LIMIT sqlc.arg(max_results)
+		) AS recent
+		WHERE follows.follower_id = sqlc.arg(user_id)
+	)
+)
+AND chirps.deleted_at IS NULL
+AND NOT EXISTS (SELECT 1 FROM hidden_authors WHERE hidden_authors.viewer_id = sqlc.arg(user_id) AND hidden_authors.author_id = chirps.user_id)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: MergeLargeAccountChirps :exec
+-- Marks the chirps of accounts with at least max_fanout_followers followers
+-- to be merged in when timelines are read
+INSERT INTO timeline_merged_chirps (chirp_id, author_id, created_at)
+SELECT chirps.id, chirps.user_id, chirps.created_at
+FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE users.follower_count >= sqlc.arg(max_fanout_followers)
+AND chirps.deleted_at IS NULL
+ON CONFLICT DO NOTHING;
+
+-- name: ListUserIDs :many
+SELECT id
+FROM users
+ORDER BY id;
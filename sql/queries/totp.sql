@@ -0,0 +1,27 @@
+-- name: SetUserTOTPSecret :exec
+UPDATE users
+SET totp_secret = $2,
+totp_enabled_at = NULL,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: EnableUserTOTP :exec
+UPDATE users
+SET totp_enabled_at = NOW(),
+totp_last_step = $2,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL,
+totp_enabled_at = NULL,
+totp_last_step = 0,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1
+AND totp_last_step < $2;
@@ -0,0 +1,14 @@
+-- name: VerifyUserEmail :one
+UPDATE users
+SET email = $2,
+email_verified_at = NOW(),
+pending_email = NULL,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserPendingEmail :exec
+UPDATE users
+SET pending_email = $2,
+updated_at = NOW()
+WHERE id = $1;
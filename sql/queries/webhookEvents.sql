@@ -0,0 +1,40 @@
+-- name: ClaimWebhookEvent :one
+-- Takes a stored event for another attempt: one that failed, or one stuck in
+-- 'received' since before stale_before
+UPDATE webhook_events
+SET status = 'received',
+claimed_at = NOW()
+WHERE provider = sqlc.arg(provider)
+AND event_id = sqlc.arg(event_id)
+AND (status = 'failed' OR (status = 'received' AND claimed_at < sqlc.arg(stale_before)))
+RETURNING *;
+
+-- name: CreateWebhookEvent :one
+INSERT INTO webhook_events (provider, event_id, event_type, payload, status, last_error, attempts, received_at, processed_at, claimed_at)
+VALUES
+($1, $2, $3, $4, 'received', NULL, 0, NOW(), NULL, NOW())
+ON CONFLICT (provider, event_id) DO NOTHING
+RETURNING *;
+
+-- name: GetWebhookEvent :one
+SELECT *
+FROM webhook_events
+WHERE provider = $1
+AND event_id = $2;
+
+-- name: FinishWebhookEvent :exec
+UPDATE webhook_events
+SET status = $3,
+last_error = $4,
+attempts = attempts + 1,
+processed_at = NOW()
+WHERE provider = $1
+AND event_id = $2;
+
+-- name: ListWebhookEvents :many
+SELECT *
+FROM webhook_events
+WHERE provider = sqlc.arg(provider)
+AND (sqlc.narg(status)::TEXT IS NULL OR status = sqlc.narg(status))
+ORDER BY received_at DESC
+LIMIT sqlc.arg(max_results);
@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE IF EXISTS refresh_tokens
+ADD COLUMN IF NOT EXISTS family_id UUID NOT NULL DEFAULT GEN_RANDOM_UUID(),
+ADD COLUMN IF NOT EXISTS replaced_by TEXT DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens(family_id);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS refresh_tokens_family_id_idx;
+
+ALTER TABLE IF EXISTS refresh_tokens
+DROP COLUMN IF EXISTS replaced_by,
+DROP COLUMN IF EXISTS family_id;
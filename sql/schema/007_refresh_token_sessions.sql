@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE IF EXISTS refresh_tokens
+ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS ip_address TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_id_idx ON refresh_tokens(user_id);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS refresh_tokens_user_id_idx;
+
+ALTER TABLE IF EXISTS refresh_tokens
+DROP COLUMN IF EXISTS last_used_at,
+DROP COLUMN IF EXISTS ip_address,
+DROP COLUMN IF EXISTS user_agent;
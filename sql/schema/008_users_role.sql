@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE IF EXISTS users
+ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
+
+
+-- +goose Down
+ALTER TABLE IF EXISTS users
+DROP COLUMN IF EXISTS role;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE IF EXISTS users
+ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP DEFAULT NULL,
+ADD COLUMN IF NOT EXISTS pending_email TEXT DEFAULT NULL;
+
+-- Accounts from before verification existed keep working
+UPDATE users SET email_verified_at = created_at;
+
+CREATE TABLE email_verifications(
+	token_hash TEXT PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL,
+	email TEXT NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP DEFAULT NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS email_verifications_user_id_idx ON email_verifications(user_id);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS email_verifications;
+
+ALTER TABLE IF EXISTS users
+DROP COLUMN IF EXISTS pending_email,
+DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE IF EXISTS users
+ADD COLUMN IF NOT EXISTS totp_secret TEXT DEFAULT NULL,
+ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP DEFAULT NULL,
+ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE recovery_codes(
+	code_hash TEXT NOT NULL,
+	user_id UUID NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP DEFAULT NULL,
+	PRIMARY KEY (user_id, code_hash),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS recovery_codes;
+
+ALTER TABLE IF EXISTS users
+DROP COLUMN IF EXISTS totp_last_step,
+DROP COLUMN IF EXISTS totp_enabled_at,
+DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE IF EXISTS users
+ADD COLUMN IF NOT EXISTS failed_login_attempts INT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS last_failed_login_at TIMESTAMP DEFAULT NULL,
+ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP DEFAULT NULL;
+
+CREATE TABLE login_ip_failures(
+	ip_address TEXT PRIMARY KEY,
+	failed_attempts INT NOT NULL,
+	last_failed_at TIMESTAMP NOT NULL,
+	blocked_until TIMESTAMP DEFAULT NULL
+);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS login_ip_failures;
+
+ALTER TABLE IF EXISTS users
+DROP COLUMN IF EXISTS locked_until,
+DROP COLUMN IF EXISTS last_failed_login_at,
+DROP COLUMN IF EXISTS failed_login_attempts;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE webhook_events(
+	provider TEXT NOT NULL,
+	event_id TEXT NOT NULL,
+	event_type TEXT NOT NULL,
+	payload JSONB NOT NULL,
+	status TEXT NOT NULL DEFAULT 'received' CHECK (status IN ('received', 'processed', 'ignored', 'failed')),
+	last_error TEXT DEFAULT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	received_at TIMESTAMP NOT NULL,
+	processed_at TIMESTAMP DEFAULT NULL,
+	PRIMARY KEY (provider, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_events_received_at_idx ON webhook_events(provider, received_at DESC);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS webhook_events;
@@ -0,0 +1,47 @@
+-- +goose Up
+CREATE TABLE subscriptions(
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL,
+	plan TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'canceled', 'expired')),
+	started_at TIMESTAMP NOT NULL,
+	renews_at TIMESTAMP NOT NULL,
+	canceled_at TIMESTAMP DEFAULT NULL,
+	ended_at TIMESTAMP DEFAULT NULL,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- A user has at most one subscription that hasn't ended
+CREATE UNIQUE INDEX IF NOT EXISTS subscriptions_current_idx ON subscriptions(user_id) WHERE status IN ('active', 'canceled');
+CREATE INDEX IF NOT EXISTS subscriptions_renews_at_idx ON subscriptions(renews_at) WHERE status IN ('active', 'canceled');
+
+CREATE TABLE subscription_events(
+	id UUID PRIMARY KEY,
+	subscription_id UUID NOT NULL,
+	user_id UUID NOT NULL,
+	event_type TEXT NOT NULL,
+	plan TEXT NOT NULL,
+	webhook_event_id TEXT DEFAULT NULL,
+	created_at TIMESTAMP NOT NULL,
+	FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS subscription_events_user_id_idx ON subscription_events(user_id, created_at DESC);
+
+-- Users upgraded before subscriptions existed get one running for a month
+INSERT INTO subscriptions (id, user_id, plan, status, started_at, renews_at, created_at, updated_at)
+SELECT GEN_RANDOM_UUID(), id, 'chirpy_red', 'active', updated_at, NOW() + INTERVAL '30 days', NOW(), NOW()
+FROM users
+WHERE is_chirpy_red = true;
+
+INSERT INTO subscription_events (id, subscription_id, user_id, event_type, plan, created_at)
+SELECT GEN_RANDOM_UUID(), id, user_id, 'started', plan, started_at
+FROM subscriptions;
+
+
+-- +goose Down
+DROP TABLE IF EXISTS subscription_events;
+DROP TABLE IF EXISTS subscriptions;
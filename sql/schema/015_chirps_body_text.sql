@@ -0,0 +1,10 @@
+-- +goose Up
+-- Chirp length limits depend on the author's tier now and are checked in
+-- the server, so the column itself is unbounded
+ALTER TABLE IF EXISTS chirps
+ALTER COLUMN body TYPE TEXT;
+
+
+-- +goose Down
+ALTER TABLE IF EXISTS chirps
+ALTER COLUMN body TYPE VARCHAR(120) USING LEFT(body, 120);
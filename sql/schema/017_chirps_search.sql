@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE IF EXISTS chirps
+ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (TO_TSVECTOR('english', body)) STORED;
+
+CREATE INDEX IF NOT EXISTS chirps_search_vector_idx ON chirps USING GIN(search_vector);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS chirps_search_vector_idx;
+
+ALTER TABLE IF EXISTS chirps
+DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE chirp_revisions(
+	id UUID PRIMARY KEY,
+	chirp_id UUID NOT NULL,
+	body TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	replaced_at TIMESTAMP NOT NULL,
+	FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS chirp_revisions_chirp_id_idx ON chirp_revisions(chirp_id, replaced_at DESC);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS chirp_revisions;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- A deleted chirp with replies stays behind as a tombstone (empty body,
+-- deleted_at set), so its thread holds together
+ALTER TABLE IF EXISTS chirps
+ADD COLUMN IF NOT EXISTS in_reply_to UUID DEFAULT NULL REFERENCES chirps(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS reply_count INT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS chirps_in_reply_to_idx ON chirps(in_reply_to, created_at, id);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS chirps_in_reply_to_idx;
+
+ALTER TABLE IF EXISTS chirps
+DROP COLUMN IF EXISTS deleted_at,
+DROP COLUMN IF EXISTS reply_count,
+DROP COLUMN IF EXISTS in_reply_to;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- A rechirp is a chirp with an empty body pointing at the original through
+-- rechirp_of. A quote chirp has a body of its own and points through quote_of.
+ALTER TABLE IF EXISTS chirps
+ADD COLUMN IF NOT EXISTS rechirp_of UUID DEFAULT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+ADD COLUMN IF NOT EXISTS quote_of UUID DEFAULT NULL REFERENCES chirps(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS rechirp_count INT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS quote_count INT NOT NULL DEFAULT 0;
+
+-- Each user can rechirp a chirp once
+CREATE UNIQUE INDEX IF NOT EXISTS chirps_user_id_rechirp_of_idx ON chirps(user_id, rechirp_of) WHERE rechirp_of IS NOT NULL;
+CREATE INDEX IF NOT EXISTS chirps_rechirp_of_idx ON chirps(rechirp_of) WHERE rechirp_of IS NOT NULL;
+CREATE INDEX IF NOT EXISTS chirps_quote_of_idx ON chirps(quote_of) WHERE quote_of IS NOT NULL;
+
+
+-- +goose Down
+DROP INDEX IF EXISTS chirps_quote_of_idx;
+DROP INDEX IF EXISTS chirps_rechirp_of_idx;
+DROP INDEX IF EXISTS chirps_user_id_rechirp_of_idx;
+
+ALTER TABLE IF EXISTS chirps
+DROP COLUMN IF EXISTS quote_count,
+DROP COLUMN IF EXISTS rechirp_count,
+DROP COLUMN IF EXISTS quote_of,
+DROP COLUMN IF EXISTS rechirp_of;
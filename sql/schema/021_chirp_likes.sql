@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS chirp_likes (
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (user_id, chirp_id)
+);
+
+-- For a user's liked list, newest first
+CREATE INDEX IF NOT EXISTS chirp_likes_user_id_created_at_idx ON chirp_likes(user_id, created_at DESC, chirp_id DESC);
+CREATE INDEX IF NOT EXISTS chirp_likes_chirp_id_idx ON chirp_likes(chirp_id);
+
+-- Kept in step with chirp_likes in the same transaction as each like and unlike
+ALTER TABLE IF EXISTS chirps
+ADD COLUMN IF NOT EXISTS like_count INT NOT NULL DEFAULT 0;
+
+
+-- +goose Down
+ALTER TABLE IF EXISTS chirps
+DROP COLUMN IF EXISTS like_count;
+
+DROP TABLE IF EXISTS chirp_likes;
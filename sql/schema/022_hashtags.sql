@@ -0,0 +1,33 @@
+-- +goose Up
+-- Tags are stored lowercased, without the #
+CREATE TABLE IF NOT EXISTS hashtags (
+	id UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(),
+	tag TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- created_at is the chirp's, copied here so tag timelines and trending
+-- don't have to look at chirps to order or window them
+CREATE TABLE IF NOT EXISTS chirp_hashtags (
+	chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+	hashtag_id UUID NOT NULL REFERENCES hashtags(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (chirp_id, hashtag_id)
+);
+
+CREATE INDEX IF NOT EXISTS chirp_hashtags_hashtag_id_created_at_idx ON chirp_hashtags(hashtag_id, created_at DESC, chirp_id DESC);
+CREATE INDEX IF NOT EXISTS chirp_hashtags_created_at_idx ON chirp_hashtags(created_at);
+
+-- Written by the trending worker; requests only read it
+CREATE TABLE IF NOT EXISTS trending_hashtags (
+	hashtag_id UUID PRIMARY KEY REFERENCES hashtags(id) ON DELETE CASCADE,
+	score DOUBLE PRECISION NOT NULL,
+	chirp_count INT NOT NULL,
+	computed_at TIMESTAMP NOT NULL
+);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS trending_hashtags;
+DROP TABLE IF EXISTS chirp_hashtags;
+DROP TABLE IF EXISTS hashtags;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- user_id is who the notification is for, actor_id who caused it
+CREATE TABLE IF NOT EXISTS notifications (
+	id UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(),
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	type TEXT NOT NULL,
+	chirp_id UUID DEFAULT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	read_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS notifications_user_id_created_at_idx ON notifications(user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS notifications_unread_idx ON notifications(user_id) WHERE read_at IS NULL;
+-- Liking, unliking and liking again (or editing a mention back in) doesn't
+-- notify twice
+CREATE UNIQUE INDEX IF NOT EXISTS notifications_once_idx ON notifications(user_id, actor_id, type, COALESCE(chirp_id, '00000000-0000-0000-0000-000000000000'::UUID));
+
+
+-- +goose Down
+DROP TABLE IF EXISTS notifications;
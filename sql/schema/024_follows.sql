@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS follows (
+	follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (follower_id, followee_id),
+	CHECK (follower_id <> followee_id)
+);
+
+-- Follower and following lists, newest first
+CREATE INDEX IF NOT EXISTS follows_followee_id_created_at_idx ON follows(followee_id, created_at DESC, follower_id DESC);
+CREATE INDEX IF NOT EXISTS follows_follower_id_created_at_idx ON follows(follower_id, created_at DESC, followee_id DESC);
+
+-- Kept in step with follows in the same transaction as each follow and unfollow
+ALTER TABLE IF EXISTS users
+ADD COLUMN IF NOT EXISTS follower_count INT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS following_count INT NOT NULL DEFAULT 0;
+
+
+-- +goose Down
+ALTER TABLE IF EXISTS users
+DROP COLUMN IF EXISTS following_count,
+DROP COLUMN IF EXISTS follower_count;
+
+DROP TABLE IF EXISTS follows;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- Each user's home timeline, filled in as the people they follow post.
+-- created_at is the chirp's, copied so a timeline can be paged from here.
+CREATE TABLE IF NOT EXISTS timeline_entries (
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+	author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX IF NOT EXISTS timeline_entries_user_id_created_at_idx ON timeline_entries(user_id, created_at DESC, chirp_id DESC);
+-- For removing someone's chirps from a timeline when they're unfollowed
+CREATE INDEX IF NOT EXISTS timeline_entries_user_id_author_id_idx ON timeline_entries(user_id, author_id);
+CREATE INDEX IF NOT EXISTS timeline_entries_chirp_id_idx ON timeline_entries(chirp_id);
+
+-- Chirps waiting to be pushed to their author's followers
+CREATE TABLE IF NOT EXISTS timeline_fanouts (
+	chirp_id UUID PRIMARY KEY REFERENCES chirps(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS timeline_fanouts;
+DROP TABLE IF EXISTS timeline_entries;
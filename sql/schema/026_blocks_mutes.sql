@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS blocks (
+	blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (blocker_id, blocked_id),
+	CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX IF NOT EXISTS blocks_blocked_id_idx ON blocks(blocked_id, blocker_id);
+CREATE INDEX IF NOT EXISTS blocks_blocker_id_created_at_idx ON blocks(blocker_id, created_at DESC, blocked_id DESC);
+
+CREATE TABLE IF NOT EXISTS mutes (
+	muter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (muter_id, muted_id),
+	CHECK (muter_id <> muted_id)
+);
+
+CREATE INDEX IF NOT EXISTS mutes_muter_id_created_at_idx ON mutes(muter_id, created_at DESC, muted_id DESC);
+
+-- Whose chirps each user doesn't see: anyone they've blocked or muted, and
+-- anyone who has blocked them
+CREATE OR REPLACE VIEW hidden_authors AS
+SELECT blocker_id AS viewer_id, blocked_id AS author_id FROM blocks
+UNION ALL
+SELECT blocked_id, blocker_id FROM blocks
+UNION ALL
+SELECT muter_id, muted_id FROM mutes;
+
+
+-- +goose Down
+DROP VIEW IF EXISTS hidden_authors;
+DROP TABLE IF EXISTS mutes;
+DROP TABLE IF EXISTS blocks;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Handles are optional until a user picks one, and unique ignoring case
+ALTER TABLE IF EXISTS users
+ADD COLUMN IF NOT EXISTS handle TEXT,
+ADD COLUMN IF NOT EXISTS display_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS bio TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS location TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS website TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_handle_lower_idx ON users(LOWER(handle));
+
+
+-- +goose Down
+DROP INDEX IF EXISTS users_handle_lower_idx;
+
+ALTER TABLE IF EXISTS users
+DROP COLUMN IF EXISTS website,
+DROP COLUMN IF EXISTS location,
+DROP COLUMN IF EXISTS bio,
+DROP COLUMN IF EXISTS display_name,
+DROP COLUMN IF EXISTS handle;
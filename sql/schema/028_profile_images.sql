@@ -0,0 +1,12 @@
+-- +goose Up
+-- Where each user's avatar and header variants are kept in the blob store,
+-- e.g. "avatars/<user id>/<upload id>"; NULL when they haven't uploaded one
+ALTER TABLE IF EXISTS users
+ADD COLUMN IF NOT EXISTS avatar_key TEXT,
+ADD COLUMN IF NOT EXISTS header_key TEXT;
+
+
+-- +goose Down
+ALTER TABLE IF EXISTS users
+DROP COLUMN IF EXISTS header_key,
+DROP COLUMN IF EXISTS avatar_key;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- When processing of an event last started, so one left in 'received' by a
+-- crash can be picked up again once it's clearly not still running
+ALTER TABLE IF EXISTS webhook_events
+ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+
+-- +goose Down
+ALTER TABLE IF EXISTS webhook_events
+DROP COLUMN IF EXISTS claimed_at;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- renews_at is NULL only for subscriptions carried over from before
+-- subscriptions existed. Nobody knows when those renew, so rather than
+-- expiring paying users on a made up date they last until Polka says
+-- they've ended. Everything started or renewed through Polka has a date.
+ALTER TABLE IF EXISTS subscriptions
+ALTER COLUMN renews_at DROP NOT NULL;
+
+-- The carried over subscriptions are the current ones no webhook has touched
+UPDATE subscriptions
+SET renews_at = NULL
+WHERE status IN ('active', 'canceled')
+AND NOT EXISTS (
+	SELECT 1
+	FROM subscription_events
+	WHERE subscription_events.subscription_id = subscriptions.id
+	AND subscription_events.webhook_event_id IS NOT NULL
+);
+
+
+-- +goose Down
+UPDATE subscriptions
+SET renews_at = NOW() + INTERVAL '30 days'
+WHERE renews_at IS NULL;
+
+ALTER TABLE IF EXISTS subscriptions
+ALTER COLUMN renews_at SET NOT NULL;
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Chirpy Red is sold through Polka as a subscription. The subscriptions
+// table is the source of truth; users.is_chirpy_red is kept in step with it
+// so the rest of the app can keep checking a single flag. Every subscription
+// started or renewed through Polka has a renews_at; only ones carried over
+// from before subscriptions existed don't, and those last until Polka tells
+// us they've ended.
+
+const planChirpyRed = "chirpy_red"
+
+// Entries in a user's billing history
+const (
+	subscriptionEventStarted    = "started"
+	subscriptionEventRenewed    = "renewed"
+	subscriptionEventCanceled   = "canceled"
+	subscriptionEventDowngraded = "downgraded"
+	subscriptionEventExpired    = "expired"
+)
+
+var errNoSubscription = errors.New("user has no current subscription")
+
+// How long until a subscription renews: up to renews_at when Polka sends
+// one, otherwise a billing period
+func renewalPeriod(renewsAt *time.Time, billingPeriod time.Duration, now time.Time) (time.Duration, error) {
+	if renewsAt == nil {
+		return billingPeriod, nil
+	}
+	period := renewsAt.Sub(now)
+	if period <= 0 {
+		return 0, errors.New("renews_at is in the past")
+	}
+	return period, nil
+}
+
+// Starts a subscription, or renews the current one. Renewing a canceled
+// subscription turns it back on.
+func (cfg *apiConfig) renewSubscription(ctx context.Context, userID uuid.UUID, plan string, period time.Duration, source sql.NullString) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	eventType := subscriptionEventRenewed
+	subscription, err := qtx.GetCurrentSubscription(ctx, userID)
+	if err == sql.ErrNoRows {
+		eventType = subscriptionEventStarted
+		subscription, err = qtx.CreateSubscription(ctx, database.CreateSubscriptionParams{
+			UserID:          userID,
+			Plan:            plan,
+			RenewsInSeconds: durationSeconds(period),
+		})
+	} else if err == nil {
+		subscription, err = qtx.RenewSubscription(ctx, database.RenewSubscriptionParams{
+			Plan:            plan,
+			RenewsInSeconds: durationSeconds(period),
+			ID:              subscription.ID,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	err = recordSubscriptionEvent(ctx, qtx, subscription, eventType, source)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Stops the subscription renewing. The user keeps Chirpy Red until the
+// period they paid for runs out and the expiry job ends it.
+func (cfg *apiConfig) cancelSubscription(ctx context.Context, userID uuid.UUID, source sql.NullString) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	subscription, err := qtx.CancelSubscription(ctx, userID)
+	if err == sql.ErrNoRows {
+		return errNoSubscription
+	}
+	if err != nil {
+		return err
+	}
+	err = recordSubscriptionEvent(ctx, qtx, subscription, subscriptionEventCanceled, source)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Ends the current subscription straight away, for downgrades and for
+// expiry notices from Polka
+func (cfg *apiConfig) endSubscription(ctx context.Context, userID uuid.UUID, eventType string, source sql.NullString) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	subscription, err := qtx.EndSubscription(ctx, userID)
+	if err == sql.ErrNoRows {
+		return errNoSubscription
+	}
+	if err != nil {
+		return err
+	}
+	err = recordSubscriptionEvent(ctx, qtx, subscription, eventType, source)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Adds the billing history entry for a change and brings is_chirpy_red
+// into line with the subscription's new status
+func recordSubscriptionEvent(ctx context.Context, qtx *database.Queries, subscription database.Subscription, eventType string, source sql.NullString) error {
+	err := qtx.CreateSubscriptionEvent(ctx, database.CreateSubscriptionEventParams{
+		SubscriptionID: subscription.ID,
+		UserID:         subscription.UserID,
+		EventType:      eventType,
+		Plan:           subscription.Plan,
+		WebhookEventID: source,
+	})
+	if err != nil {
+		return err
+	}
+	return qtx.SetUserChirpyRed(ctx, database.SetUserChirpyRedParams{
+		ID:          subscription.UserID,
+		IsChirpyRed: sql.NullBool{Bool: subscription.Status != "expired", Valid: true},
+	})
+}
+
+// Ends every subscription whose renewal date has passed without a renewal
+func (cfg *apiConfig) expireLapsedSubscriptions(ctx context.Context) (int, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	expired, err := qtx.ExpireLapsedSubscriptions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, subscription := range expired {
+		err = recordSubscriptionEvent(ctx, qtx, subscription, subscriptionEventExpired, sql.NullString{})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), tx.Commit()
+}
+
+// Background job started from main. Runs until the process exits.
+func (cfg *apiConfig) runSubscriptionExpiry(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		expired, err := cfg.expireLapsedSubscriptions(context.Background())
+		if err != nil {
+			log.Printf("ERROR: expiring subscriptions: %v", err)
+			continue
+		}
+		if expired > 0 {
+			log.Printf("Expired %v lapsed subscriptions", expired)
+		}
+	}
+}
+
+// The caller's latest subscription and their billing history, newest first
+func (cfg *apiConfig) getSubscription(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Subscription *Subscription  `json:"subscription"`
+		History      []BillingEvent `json:"history"`
+	}
+	userID := requestPrincipal(r).UserID
+
+	ctx := context.Background()
+	resp := response{History: []BillingEvent{}}
+	subscription, err := cfg.dbQuerries.GetLatestSubscription(ctx, userID)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("ERROR: getting subscription for %v: %v", userID, err)
+		respondWithError(w, 500, "Unable to get subscription")
+		return
+	}
+	if err == nil {
+		resp.Subscription = &Subscription{
+			ID:         subscription.ID,
+			Plan:       subscription.Plan,
+			Status:     subscription.Status,
+			StartedAt:  subscription.StartedAt,
+			RenewsAt:   nullTime(subscription.RenewsAt),
+			CanceledAt: nullTime(subscription.CanceledAt),
+			EndedAt:    nullTime(subscription.EndedAt),
+		}
+	}
+	events, err := cfg.dbQuerries.GetSubscriptionEventsByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("ERROR: getting billing history for %v: %v", userID, err)
+		respondWithError(w, 500, "Unable to get subscription")
+		return
+	}
+	for _, event := range events {
+		resp.History = append(resp.History, BillingEvent{
+			ID:             event.ID,
+			SubscriptionID: event.SubscriptionID,
+			Event:          event.EventType,
+			Plan:           event.Plan,
+			CreatedAt:      event.CreatedAt,
+		})
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
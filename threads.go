@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Replies point at their parent through in_reply_to, and each chirp keeps a
+// count of its direct replies. Deleting a chirp that has replies leaves a
+// tombstone so the rest of the thread stays reachable; once the last reply
+// under a tombstone goes, the tombstone goes too.
+
+// Empties the chirp and drops its old revisions, rechirps, likes, tags and
+// timeline entries, keeping the row for replies
+func tombstoneChirp(ctx context.Context, qtx *database.Queries, chirpID uuid.UUID) error {
+	err := qtx.TombstoneChirp(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	err = qtx.DeleteRechirpsOf(ctx, uuid.NullUUID{UUID: chirpID, Valid: true})
+	if err != nil {
+		return err
+	}
+	err = qtx.DeleteChirpLikes(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	err = qtx.UntagChirp(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	err = qtx.DeleteTimelineEntriesForChirp(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	return qtx.DeleteChirpRevisions(ctx, chirpID)
+}
+
+// Deletes a chirp without replies, then walks up the thread removing any
+// tombstones left with nothing under them
+func deleteChirpAndTombstones(ctx context.Context, qtx *database.Queries, chirpID, userID uuid.UUID, parent uuid.NullUUID) error {
+	_, err := qtx.DeleteChirp(ctx, database.DeleteChirpParams{ID: chirpID, UserID: userID})
+	if err != nil {
+		return err
+	}
+	for parent.Valid {
+		updated, err := qtx.DecrementReplyCount(ctx, parent.UUID)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !updated.DeletedAt.Valid || updated.ReplyCount > 0 {
+			return nil
+		}
+		_, err = qtx.DeleteChirp(ctx, database.DeleteChirpParams{ID: parent.UUID, UserID: updated.UserID})
+		if err != nil {
+			return err
+		}
+		parent = updated.InReplyTo
+	}
+	return nil
+}
+
+// Replies are sent depth first, each after its parent, with siblings oldest
+// first. The cursor is the path from the thread's chirp to the last reply sent.
+func encodeThreadCursor(path []string) string {
+	data, _ := json.Marshal(path)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeThreadCursor(cursor string) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+	path := []string{}
+	err = json.Unmarshal(data, &path)
+	if err != nil || len(path) == 0 {
+		return nil, errors.New("malformed cursor")
+	}
+	return path, nil
+}
+
+// A chirp, everything above it in its thread (root first), and a page of
+// everything below it
+func (cfg *apiConfig) getChirpThread(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Ancestors []Chirp       `json:"ancestors"`
+		Chirp     Chirp         `json:"chirp"`
+		Replies   []ThreadReply `json:"replies"`
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Bad chirp ID")
+		return
+	}
+	query := r.URL.Query()
+	limit, err := pageSize(query)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	afterPath := []string{}
+	if cursorParam := query.Get("cursor"); cursorParam != "" {
+		afterPath, err = decodeThreadCursor(cursorParam)
+		if err != nil {
+			respondWithError(w, 400, err.Error())
+			return
+		}
+	}
+
+	ctx := context.Background()
+	chirp, err := cfg.dbQuerries.GetChirpByChirpID(ctx, chirpID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: loading chirp %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to get thread")
+		return
+	}
+	ancestors, err := cfg.dbQuerries.GetChirpAncestors(ctx, chirpID)
+	if err != nil {
+		log.Printf("ERROR: loading ancestors of %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to get thread")
+		return
+	}
+	replies, err := cfg.dbQuerries.GetChirpReplies(ctx, database.GetChirpRepliesParams{
+		ID:         chirpID,
+		AfterPath:  afterPath,
+		MaxResults: limit + 1,
+	})
+	if err != nil {
+		log.Printf("ERROR: loading replies to %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to get thread")
+		return
+	}
+	if len(replies) > int(limit) {
+		replies = replies[:limit]
+		setNextPage(w, r, encodeThreadCursor(replies[len(replies)-1].Path))
+	}
+
+	resp := response{
+		Ancestors: []Chirp{},
+		Chirp:     chirpResponse(chirp),
+		Replies:   []ThreadReply{},
+	}
+	for _, row := range ancestors {
+		resp.Ancestors = append(resp.Ancestors, chirpResponse(database.Chirp{
+			ID:           row.ID,
+			CreatedAt:    row.CreatedAt,
+			UpdatedAt:    row.UpdatedAt,
+			Body:         row.Body,
+			UserID:       row.UserID,
+			InReplyTo:    row.InReplyTo,
+			ReplyCount:   row.ReplyCount,
+			DeletedAt:    row.DeletedAt,
+			RechirpOf:    row.RechirpOf,
+			QuoteOf:      row.QuoteOf,
+			RechirpCount: row.RechirpCount,
+			QuoteCount:   row.QuoteCount,
+			LikeCount:    row.LikeCount,
+		}))
+	}
+	for _, row := range replies {
+		resp.Replies = append(resp.Replies, ThreadReply{
+			Chirp: chirpResponse(database.Chirp{
+				ID:           row.ID,
+				CreatedAt:    row.CreatedAt,
+				UpdatedAt:    row.UpdatedAt,
+				Body:         row.Body,
+				UserID:       row.UserID,
+				InReplyTo:    row.InReplyTo,
+				ReplyCount:   row.ReplyCount,
+				DeletedAt:    row.DeletedAt,
+				RechirpOf:    row.RechirpOf,
+				QuoteOf:      row.QuoteOf,
+				RechirpCount: row.RechirpCount,
+				QuoteCount:   row.QuoteCount,
+				LikeCount:    row.LikeCount,
+			}),
+			Depth: row.Depth,
+		})
+	}
+	embedded := []*Chirp{&resp.Chirp}
+	for i := range resp.Ancestors {
+		embedded = append(embedded, &resp.Ancestors[i])
+	}
+	for i := range resp.Replies {
+		embedded = append(embedded, &resp.Replies[i].Chirp)
+	}
+	err = cfg.prepareChirps(ctx, r, embedded)
+	if err != nil {
+		log.Printf("ERROR: loading quoted chirps in thread %v: %v", chirpID, err)
+		respondWithError(w, 500, "Unable to get thread")
+		return
+	}
+	// Hidden chirps elsewhere in the thread stay, blanked, so it holds together
+	if resp.Chirp.Hidden {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	respondWithJSON(w, 200, resp)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Home timelines are read from timeline_entries, a row per chirp per user
+// whose timeline it's on. Authors see their own chirps straight away; a
+// background worker pushes them to followers from the timeline_fanouts
+// queue. Chirps posted while their author has fanoutMaxFollowers or more
+// followers are never pushed; they're kept in timeline_merged_chirps and
+// ListTimeline merges them in when a timeline is read, so they stay there
+// whatever happens to the author's follower count later.
+
+const (
+	// How many of an author's chirps go in when they're followed, or per
+	// author when a timeline is rebuilt
+	timelineBackfillSize = 100
+	// How many queued chirps the worker pushes per transaction
+	timelineFanoutBatch = 10
+)
+
+// Puts a new chirp on its author's timeline and either queues it for their
+// followers or, for a large account, marks it to be merged in when timelines
+// are read. The caller wakes the worker once the transaction has committed.
+func (cfg *apiConfig) addToTimelines(ctx context.Context, qtx *database.Queries, chirp database.Chirp, author database.User) error {
+	err := qtx.AddOwnTimelineEntry(ctx, chirp.ID)
+	if err != nil {
+		return err
+	}
+	if author.FollowerCount >= cfg.fanoutMaxFollowers {
+		return qtx.AddTimelineMergedChirp(ctx, chirp.ID)
+	}
+	return qtx.EnqueueTimelineFanout(ctx, chirp.ID)
+}
+
+// Lets the worker know there's something queued without waiting for its
+// next tick. Never blocks; one pending wake-up is enough.
+func (cfg *apiConfig) wakeTimelineFanout() {
+	select {
+	case cfg.fanoutWake <- struct{}{}:
+	default:
+	}
+}
+
+// Pushes queued chirps to followers' timelines until the queue is empty.
+// Rows are claimed with SKIP LOCKED, so several servers can share the work.
+func (cfg *apiConfig) fanOutPending(ctx context.Context) error {
+	for {
+		done, err := cfg.fanOutBatch(ctx)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+func (cfg *apiConfig) fanOutBatch(ctx context.Context) (bool, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	chirpIDs, err := qtx.ClaimTimelineFanouts(ctx, timelineFanoutBatch)
+	if err != nil {
+		return false, err
+	}
+	for _, chirpID := range chirpIDs {
+		// A chirp deleted while queued has no follower rows to add
+		_, err = qtx.FanOutChirp(ctx, chirpID)
+		if err != nil {
+			return false, err
+		}
+		err = qtx.DeleteTimelineFanout(ctx, chirpID)
+		if err != nil {
+			return false, err
+		}
+	}
+	return len(chirpIDs) < timelineFanoutBatch, tx.Commit()
+}
+
+// Background job started from main. Drains the queue whenever a chirp is
+// posted, and every interval in case a wake-up was missed or another server
+// queued something.
+func (cfg *apiConfig) runTimelineFanout(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := cfg.fanOutPending(context.Background())
+		if err != nil {
+			log.Printf("ERROR: fanning out chirps: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-cfg.fanoutWake:
+		}
+	}
+}
+
+// Throws away the given users' timelines and builds them again from who they
+// follow, or every user's when none are given. First, every chirp by an
+// account that's now over fanoutMaxFollowers is marked to be merged in when
+// timelines are read, which brings installs that don't use the default
+// limit into line after migrating.
+func (cfg *apiConfig) rebuildTimelines(ctx context.Context, userIDs []uuid.UUID) error {
+	err := cfg.dbQuerries.MergeLargeAccountChirps(ctx, cfg.fanoutMaxFollowers)
+	if err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		userIDs, err = cfg.dbQuerries.ListUserIDs(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	for _, userID := range userIDs {
+		err := cfg.rebuildTimeline(ctx, userID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Readers see either the old timeline or the new one, never an empty one
+func (cfg *apiConfig) rebuildTimeline(ctx context.Context, userID uuid.UUID) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+
+	err = qtx.ClearTimeline(ctx, userID)
+	if err != nil {
+		return err
+	}
+	err = qtx.RebuildTimeline(ctx, database.RebuildTimelineParams{
+		UserID:    userID,
+		PerAuthor: timelineBackfillSize,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
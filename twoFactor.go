@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	auth "github.com/avgra3/chirpy/internal/auth"
+	"github.com/avgra3/chirpy/internal/database"
+)
+
+const (
+	totpIssuer           = "Chirpy"
+	recoveryCodeCount    = 10
+	loginChallengeExpiry = 5 * time.Minute
+)
+
+// First half of a two-factor login. The challenge token can't be used for
+// anything except POST /api/login/2fa.
+func (cfg *apiConfig) respondWithLoginChallenge(w http.ResponseWriter, user database.User) {
+	challenge, err := cfg.keyRing.MakeJWT(auth.Principal{
+		UserID: user.ID,
+		Scopes: []string{auth.ScopeMFAChallenge},
+	}, loginChallengeExpiry)
+	if err != nil {
+		respondWithError(w, 500, "Unable to create token at this time")
+		return
+	}
+	type responseValue struct {
+		MFARequired    bool   `json:"mfa_required"`
+		ChallengeToken string `json:"challenge_token"`
+	}
+	respondWithJSON(w, 200, responseValue{MFARequired: true, ChallengeToken: challenge})
+}
+
+// Checks a TOTP code or a recovery code, using up whichever one matched
+func (cfg *apiConfig) checkSecondFactor(ctx context.Context, user database.User, code, recoveryCode string) (bool, error) {
+	if recoveryCode != "" {
+		used, err := cfg.dbQuerries.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+			UserID:   user.ID,
+			CodeHash: auth.HashOneTimeToken(auth.NormalizeRecoveryCode(recoveryCode)),
+		})
+		return used == 1, err
+	}
+	step, ok := auth.ValidateTOTP(user.TotpSecret.String, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	// A code can't be replayed, and neither can any code before it
+	used, err := cfg.dbQuerries.UseTOTPStep(ctx, database.UseTOTPStepParams{
+		ID:           user.ID,
+		TotpLastStep: step,
+	})
+	return used == 1, err
+}
+
+func (cfg *apiConfig) loginSecondFactor(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recovery_code"`
+	}
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, 500, "couldn't read request")
+		return
+	}
+	params := parameters{}
+	err = json.Unmarshal(data, &params)
+	if err != nil {
+		respondWithError(w, 400, "couldn't unmarshal parameters")
+		return
+	}
+	challenge, err := cfg.keyRing.ValidateJWT(params.ChallengeToken)
+	if err != nil || !challenge.HasScope(auth.ScopeMFAChallenge) {
+		respondWithError(w, 401, "Invalid or expired challenge")
+		return
+	}
+
+	ctx := context.Background()
+	user, err := cfg.dbQuerries.GetUserById(ctx, challenge.UserID)
+	if err != nil || !user.TotpEnabledAt.Valid {
+		respondWithError(w, 401, "Invalid or expired challenge")
+		return
+	}
+	// Codes are short, so guessing them is throttled just like passwords
+	ip := clientIP(r)
+	retryAfter, err := cfg.ipRetryAfter(ctx, ip)
+	if err == nil && retryAfter == 0 {
+		retryAfter, err = cfg.accountRetryAfter(ctx, user.ID)
+	}
+	if err != nil {
+		respondWithError(w, 500, "Unable to log in")
+		return
+	}
+	if retryAfter > 0 {
+		respondWithRetryAfter(w, retryAfter)
+		return
+	}
+	ok, err := cfg.checkSecondFactor(ctx, user, params.Code, params.RecoveryCode)
+	if err != nil {
+		log.Printf("ERROR: checking second factor: %v", err)
+		respondWithError(w, 500, "Unable to log in")
+		return
+	}
+	if !ok {
+		cfg.recordFailedLogin(ctx, ip, user.ID)
+		respondWithError(w, 401, "Incorrect code")
+		return
+	}
+	cfg.completeLogin(w, r, user)
+}
+
+// Starts TOTP enrollment. 2FA isn't on until a code from the new secret has
+// been confirmed, so a botched QR scan can't lock anyone out.
+func (cfg *apiConfig) enrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userID := requestPrincipal(r).UserID
+	ctx := context.Background()
+	user, err := cfg.dbQuerries.GetUserById(ctx, userID)
+	if err != nil {
+		respondWithError(w, 404, "User does not exist")
+		return
+	}
+	if user.TotpEnabledAt.Valid {
+		respondWithError(w, 409, "Two-factor authentication is already enabled")
+		return
+	}
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		respondWithError(w, 500, "Unable to create secret")
+		return
+	}
+	err = cfg.dbQuerries.SetUserTOTPSecret(ctx, database.SetUserTOTPSecretParams{
+		ID:         userID,
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+	})
+	if err != nil {
+		log.Printf("ERROR: saving TOTP secret: %v", err)
+		respondWithError(w, 500, "Unable to create secret")
+		return
+	}
+
+	type responseValue struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}
+	respondWithJSON(w, 201, responseValue{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(secret, user.Email, totpIssuer),
+	})
+}
+
+func (cfg *apiConfig) confirmTOTP(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code string `json:"code"`
+	}
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, 500, "couldn't read request")
+		return
+	}
+	params := parameters{}
+	err = json.Unmarshal(data, &params)
+	if err != nil {
+		respondWithError(w, 400, "couldn't unmarshal parameters")
+		return
+	}
+
+	userID := requestPrincipal(r).UserID
+	ctx := context.Background()
+	user, err := cfg.dbQuerries.GetUserById(ctx, userID)
+	if err != nil {
+		respondWithError(w, 404, "User does not exist")
+		return
+	}
+	if user.TotpEnabledAt.Valid {
+		respondWithError(w, 409, "Two-factor authentication is already enabled")
+		return
+	}
+	if !user.TotpSecret.Valid {
+		respondWithError(w, 400, "Start enrollment first")
+		return
+	}
+	step, ok := auth.ValidateTOTP(user.TotpSecret.String, params.Code, time.Now())
+	if !ok {
+		respondWithError(w, 400, "Incorrect code")
+		return
+	}
+	recoveryCodes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		respondWithError(w, 500, "Unable to create recovery codes")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, 500, "Unable to enable two-factor authentication")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQuerries.WithTx(tx)
+	err = qtx.EnableUserTOTP(ctx, database.EnableUserTOTPParams{ID: userID, TotpLastStep: step})
+	if err == nil {
+		err = qtx.DeleteRecoveryCodes(ctx, userID)
+	}
+	for _, code := range recoveryCodes {
+		if err != nil {
+			break
+		}
+		err = qtx.CreateRecoveryCode(ctx, database.CreateRecoveryCodeParams{
+			CodeHash: auth.HashOneTimeToken(code),
+			UserID:   userID,
+		})
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("ERROR: enabling TOTP: %v", err)
+		respondWithError(w, 500, "Unable to enable two-factor authentication")
+		return
+	}
+
+	// The only time the recovery codes are ever shown
+	type responseValue struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	respondWithJSON(w, 200, responseValue{RecoveryCodes: recoveryCodes})
+}
+
+// Turning 2FA off needs a second factor too, not just a stolen access token
+func (cfg *apiConfig) disableTOTP(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, 500, "couldn't read request")
+		return
+	}
+	params := parameters{}
+	err = json.Unmarshal(data, &params)
+	if err != nil {
+		respondWithError(w, 400, "couldn't unmarshal parameters")
+		return
+	}
+
+	userID := requestPrincipal(r).UserID
+	ctx := context.Background()
+	user, err := cfg.dbQuerries.GetUserById(ctx, userID)
+	if err != nil {
+		respondWithError(w, 404, "User does not exist")
+		return
+	}
+	if !user.TotpEnabledAt.Valid {
+		respondWithError(w, 409, "Two-factor authentication is not enabled")
+		return
+	}
+	ok, err := cfg.checkSecondFactor(ctx, user, params.Code, params.RecoveryCode)
+	if err != nil {
+		log.Printf("ERROR: checking second factor: %v", err)
+		respondWithError(w, 500, "Unable to disable two-factor authentication")
+		return
+	}
+	if !ok {
+		respondWithError(w, 401, "Incorrect code")
+		return
+	}
+
+	err = cfg.dbQuerries.DisableUserTOTP(ctx, userID)
+	if err == nil {
+		err = cfg.dbQuerries.DeleteRecoveryCodes(ctx, userID)
+	}
+	if err != nil {
+		log.Printf("ERROR: disabling TOTP: %v", err)
+		respondWithError(w, 500, "Unable to disable two-factor authentication")
+		return
+	}
+	w.WriteHeader(204)
+}
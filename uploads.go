@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/avgra3/chirpy/internal/blobstore"
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/avgra3/chirpy/internal/entitlements"
+	"github.com/avgra3/chirpy/internal/imaging"
+	"github.com/google/uuid"
+)
+
+// Avatars and headers are resized into a few fixed sizes when they're
+// uploaded and kept in the blob store under a key that's new for every
+// upload, so they're served from /media/ with a cache lifetime of a year.
+// The users row only holds that key.
+
+// Room for the multipart headers around the image itself
+const multipartOverhead = 64 << 10
+
+// An avatar or a header: the sizes it's kept in, and the users column
+// holding its key
+type profileImage struct {
+	name   string
+	prefix string
+	sizes  []imaging.Size
+	key    func(database.User) sql.NullString
+	setKey func(context.Context, *database.Queries, database.SetAvatarKeyParams) (database.User, error)
+}
+
+var avatarImage = profileImage{
+	name:   "avatar",
+	prefix: "avatars",
+	sizes: []imaging.Size{
+		{Name: "small", Width: 48, Height: 48},
+		{Name: "medium", Width: 200, Height: 200},
+		{Name: "large", Width: 400, Height: 400},
+	},
+	key: func(user database.User) sql.NullString { return user.AvatarKey },
+	setKey: func(ctx context.Context, q *database.Queries, params database.SetAvatarKeyParams) (database.User, error) {
+		return q.SetAvatarKey(ctx, params)
+	},
+}
+
+var headerImage = profileImage{
+	name:   "header",
+	prefix: "headers",
+	sizes: []imaging.Size{
+		{Name: "small", Width: 600, Height: 200},
+		{Name: "large", Width: 1500, Height: 500},
+	},
+	key: func(user database.User) sql.NullString { return user.HeaderKey },
+	setKey: func(ctx context.Context, q *database.Queries, params database.SetAvatarKeyParams) (database.User, error) {
+		return q.SetHeaderKey(ctx, database.SetHeaderKeyParams(params))
+	},
+}
+
+func variantKey(key, size string) string {
+	return key + "/" + size + ".jpg"
+}
+
+// The URL of each size, or nil when there's no image
+func (image profileImage) urls(user database.User) map[string]string {
+	key := image.key(user)
+	if !key.Valid {
+		return nil
+	}
+	urls := map[string]string{}
+	for _, size := range image.sizes {
+		urls[size.Name] = "/media/" + variantKey(key.String, size.Name)
+	}
+	return urls
+}
+
+// Removes every size of an image; anything left behind is only wasted space
+func (cfg *apiConfig) deleteImageVariants(ctx context.Context, image profileImage, key string) {
+	for _, size := range image.sizes {
+		err := cfg.blobs.Delete(ctx, variantKey(key, size.Name))
+		if err != nil {
+			log.Printf("ERROR: deleting %v: %v", variantKey(key, size.Name), err)
+		}
+	}
+}
+
+func (cfg *apiConfig) uploadAvatar(w http.ResponseWriter, r *http.Request) {
+	cfg.uploadProfileImage(w, r, avatarImage)
+}
+
+func (cfg *apiConfig) uploadHeader(w http.ResponseWriter, r *http.Request) {
+	cfg.uploadProfileImage(w, r, headerImage)
+}
+
+func (cfg *apiConfig) deleteAvatar(w http.ResponseWriter, r *http.Request) {
+	cfg.deleteProfileImage(w, r, avatarImage)
+}
+
+func (cfg *apiConfig) deleteHeader(w http.ResponseWriter, r *http.Request) {
+	cfg.deleteProfileImage(w, r, headerImage)
+}
+
+// Takes the image from the "image" field of a multipart form. How big it may
+// be depends on the user's tier.
+func (cfg *apiConfig) uploadProfileImage(w http.ResponseWriter, r *http.Request, image profileImage) {
+	userID := requestPrincipal(r).UserID
+	ctx := context.Background()
+	user, err := cfg.dbQuerries.GetUserById(ctx, userID)
+	if err != nil {
+		respondWithError(w, 401, "User does not exist")
+		return
+	}
+	limit := cfg.userLimit(user, entitlements.UploadBytes)
+	tooLarge := fmt.Sprintf("Image is too large (limit is %v bytes)", limit)
+	r.Body = http.MaxBytesReader(w, r.Body, limit+multipartOverhead)
+	err = r.ParseMultipartForm(limit)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, 413, tooLarge)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, "Send the image as multipart/form-data")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		respondWithError(w, 400, "Missing image")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		respondWithError(w, 500, "couldn't read request")
+		return
+	}
+	if !cfg.userMay(user, entitlements.UploadBytes, int64(len(data))) {
+		respondWithError(w, 413, tooLarge)
+		return
+	}
+
+	variants, err := imaging.Resize(data, image.sizes)
+	if err == imaging.ErrUnsupported {
+		respondWithError(w, 415, "Images must be JPEG, PNG, GIF or WebP")
+		return
+	}
+	if err == imaging.ErrTooLarge {
+		respondWithError(w, 400, "Image dimensions are too large")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 400, "Couldn't read image")
+		return
+	}
+
+	key := fmt.Sprintf("%v/%v/%v", image.prefix, userID, uuid.New())
+	for _, variant := range variants {
+		err = cfg.blobs.Put(ctx, variantKey(key, variant.Name), variant.Data)
+		if err != nil {
+			log.Printf("ERROR: storing %v of %v: %v", image.name, userID, err)
+			cfg.deleteImageVariants(ctx, image, key)
+			respondWithError(w, 500, "Unable to save "+image.name)
+			return
+		}
+	}
+	updated, err := image.setKey(ctx, cfg.dbQuerries, database.SetAvatarKeyParams{
+		Key: sql.NullString{String: key, Valid: true},
+		ID:  userID,
+	})
+	if err != nil {
+		log.Printf("ERROR: saving %v of %v: %v", image.name, userID, err)
+		cfg.deleteImageVariants(ctx, image, key)
+		respondWithError(w, 500, "Unable to save "+image.name)
+		return
+	}
+	if old := image.key(user); old.Valid {
+		cfg.deleteImageVariants(ctx, image, old.String)
+	}
+	respondWithJSON(w, 200, profileResponse(updated))
+}
+
+func (cfg *apiConfig) deleteProfileImage(w http.ResponseWriter, r *http.Request, image profileImage) {
+	userID := requestPrincipal(r).UserID
+	ctx := context.Background()
+	user, err := cfg.dbQuerries.GetUserById(ctx, userID)
+	if err != nil {
+		respondWithError(w, 401, "User does not exist")
+		return
+	}
+	_, err = image.setKey(ctx, cfg.dbQuerries, database.SetAvatarKeyParams{ID: userID})
+	if err != nil {
+		log.Printf("ERROR: removing %v of %v: %v", image.name, userID, err)
+		respondWithError(w, 500, "Unable to remove "+image.name)
+		return
+	}
+	if old := image.key(user); old.Valid {
+		cfg.deleteImageVariants(ctx, image, old.String)
+	}
+	w.WriteHeader(204)
+}
+
+// Serves uploads from the blob store. Keys are never reused, so whatever is
+// at one can be cached for good.
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	blob, err := cfg.blobs.Open(r.Context(), key)
+	if err == blobstore.ErrNotFound || err == blobstore.ErrInvalidKey {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: opening %v: %v", key, err)
+		respondWithError(w, 500, "Unable to get file")
+		return
+	}
+	defer blob.Close()
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.WriteHeader(200)
+	io.Copy(w, blob)
+}
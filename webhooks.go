@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	auth "github.com/avgra3/chirpy/internal/auth"
+	"github.com/avgra3/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Every webhook we receive is stored in webhook_events before we act on it,
+// keyed by (provider, event id). A redelivery of an event we've already
+// handled is acknowledged without doing anything, and admins can look
+// through the stored events and replay ones that failed. Events without an
+// id of their own can't be told apart from a new event with the same body,
+// so they're stored under a generated id and never deduplicated.
+
+const (
+	webhookProviderPolka = "polka"
+	// How far a signed webhook's timestamp may be from our clock
+	polkaSignatureTolerance = 5 * time.Minute
+	// An event still 'received' after this long was abandoned mid-processing
+	// (say by a crash) and is retried on redelivery
+	webhookProcessingTimeout = 5 * time.Minute
+)
+
+const (
+	webhookStatusReceived  = "received"
+	webhookStatusProcessed = "processed"
+	webhookStatusIgnored   = "ignored"
+	webhookStatusFailed    = "failed"
+)
+
+var errWebhookUserNotFound = errors.New("user does not exist")
+
+type polkaEvent struct {
+	ID    string `json:"id"`
+	Event string `json:"event"`
+	Data  struct {
+		UserID   uuid.UUID  `json:"user_id"`
+		Plan     string     `json:"plan"`
+		RenewsAt *time.Time `json:"renews_at"`
+	} `json:"data"`
+}
+
+func (cfg *apiConfig) upgradeUserToRed(w http.ResponseWriter, r *http.Request) {
+	apiKey, err := auth.GetAPIKey(r.Header)
+	if err != nil || cfg.polkaKey == "" || subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.polkaKey)) != 1 {
+		w.WriteHeader(401)
+		return
+	}
+
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, 400, "Unable to read body")
+		return
+	}
+	// Signatures are optional until every Polka account has them turned on
+	if cfg.polkaWebhookSecret != "" {
+		err = auth.VerifyWebhookSignature(
+			cfg.polkaWebhookSecret,
+			data,
+			r.Header.Get("X-Polka-Timestamp"),
+			r.Header.Get("X-Polka-Signature"),
+			time.Now(),
+			polkaSignatureTolerance,
+		)
+		if err != nil {
+			log.Printf("WARNING: rejecting Polka webhook: %v", err)
+			w.WriteHeader(401)
+			return
+		}
+	}
+	params := polkaEvent{}
+	err = json.Unmarshal(data, &params)
+	if err != nil {
+		respondWithError(w, 400, "couldn't unmarshal parameters")
+		return
+	}
+
+	ctx := context.Background()
+	eventID := polkaEventID(params)
+	event, err := cfg.dbQuerries.CreateWebhookEvent(ctx, database.CreateWebhookEventParams{
+		Provider:  webhookProviderPolka,
+		EventID:   eventID,
+		EventType: params.Event,
+		Payload:   data,
+	})
+	if err == sql.ErrNoRows {
+		// Already stored, so this is a redelivery. Failed events, and ones
+		// abandoned mid-processing, are retried.
+		event, err = cfg.dbQuerries.ClaimWebhookEvent(ctx, database.ClaimWebhookEventParams{
+			Provider:    webhookProviderPolka,
+			EventID:     eventID,
+			StaleBefore: time.Now().Add(-webhookProcessingTimeout),
+		})
+	}
+	if err == sql.ErrNoRows {
+		event, err = cfg.dbQuerries.GetWebhookEvent(ctx, database.GetWebhookEventParams{
+			Provider: webhookProviderPolka,
+			EventID:  eventID,
+		})
+		if err != nil {
+			log.Printf("ERROR: looking up webhook event: %v", err)
+			respondWithError(w, 500, "Unable to store event")
+			return
+		}
+		if event.Status == webhookStatusReceived {
+			respondWithError(w, 409, "Event is already being processed")
+			return
+		}
+		w.WriteHeader(204)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: storing webhook event: %v", err)
+		respondWithError(w, 500, "Unable to store event")
+		return
+	}
+
+	err = cfg.processPolkaEvent(ctx, event)
+	if err == errWebhookUserNotFound {
+		respondWithError(w, 404, "User does not exist")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Unable to process event")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// Only an id from Polka identifies a redelivery: two events with the same
+// body (say a renewal's user.upgraded) are still two events.
+func polkaEventID(params polkaEvent) string {
+	if params.ID != "" {
+		return params.ID
+	}
+	return "generated:" + uuid.NewString()
+}
+
+// Acts on a stored event and records how it went
+func (cfg *apiConfig) processPolkaEvent(ctx context.Context, event database.WebhookEvent) error {
+	status, err := cfg.applyPolkaEvent(ctx, event)
+	lastError := sql.NullString{}
+	if err != nil {
+		log.Printf("ERROR: processing Polka event %v: %v", event.EventID, err)
+		status = webhookStatusFailed
+		lastError = sql.NullString{String: err.Error(), Valid: true}
+	}
+	finishErr := cfg.dbQuerries.FinishWebhookEvent(ctx, database.FinishWebhookEventParams{
+		Provider:  event.Provider,
+		EventID:   event.EventID,
+		Status:    status,
+		LastError: lastError,
+	})
+	if finishErr != nil {
+		log.Printf("ERROR: recording Polka event %v as %v: %v", event.EventID, status, finishErr)
+		if err == nil {
+			err = finishErr
+		}
+	}
+	return err
+}
+
+func (cfg *apiConfig) applyPolkaEvent(ctx context.Context, event database.WebhookEvent) (string, error) {
+	params := polkaEvent{}
+	err := json.Unmarshal(event.Payload, &params)
+	if err != nil {
+		return "", err
+	}
+	switch params.Event {
+	case "user.upgraded", "user.downgraded", "user.subscription_canceled", "user.subscription_expired":
+	default:
+		return webhookStatusIgnored, nil
+	}
+	_, err = cfg.dbQuerries.GetUserById(ctx, params.Data.UserID)
+	if err == sql.ErrNoRows {
+		return "", errWebhookUserNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	source := sql.NullString{String: event.EventID, Valid: true}
+	switch params.Event {
+	case "user.upgraded":
+		plan := params.Data.Plan
+		if plan == "" {
+			plan = planChirpyRed
+		}
+		var period time.Duration
+		period, err = renewalPeriod(params.Data.RenewsAt, cfg.subscriptionPeriod, time.Now())
+		if err != nil {
+			return "", err
+		}
+		err = cfg.renewSubscription(ctx, params.Data.UserID, plan, period, source)
+	case "user.downgraded":
+		err = cfg.endSubscription(ctx, params.Data.UserID, subscriptionEventDowngraded, source)
+	case "user.subscription_canceled":
+		err = cfg.cancelSubscription(ctx, params.Data.UserID, source)
+	case "user.subscription_expired":
+		err = cfg.endSubscription(ctx, params.Data.UserID, subscriptionEventExpired, source)
+	}
+	// Nothing to change, e.g. a cancellation for someone who never subscribed
+	if err == errNoSubscription {
+		return webhookStatusIgnored, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return webhookStatusProcessed, nil
+}
+
+func (cfg *apiConfig) listPolkaEvents(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	limit := int32(50)
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || parsed < 1 || parsed > 200 {
+			respondWithError(w, 400, "limit must be between 1 and 200")
+			return
+		}
+		limit = int32(parsed)
+	}
+
+	ctx := context.Background()
+	rows, err := cfg.dbQuerries.ListWebhookEvents(ctx, database.ListWebhookEventsParams{
+		Provider:   webhookProviderPolka,
+		Status:     sql.NullString{String: status, Valid: status != ""},
+		MaxResults: limit,
+	})
+	if err != nil {
+		log.Printf("ERROR: listing webhook events: %v", err)
+		respondWithError(w, 500, "Unable to list events")
+		return
+	}
+	events := []WebhookEvent{}
+	for _, row := range rows {
+		events = append(events, webhookEventResponse(row))
+	}
+	respondWithJSON(w, 200, events)
+}
+
+// Runs a stored event again, whatever happened to it the first time
+func (cfg *apiConfig) replayPolkaEvent(w http.ResponseWriter, r *http.Request) {
+	key := database.GetWebhookEventParams{
+		Provider: webhookProviderPolka,
+		EventID:  r.PathValue("eventID"),
+	}
+	ctx := context.Background()
+	event, err := cfg.dbQuerries.GetWebhookEvent(ctx, key)
+	if err == sql.ErrNoRows {
+		respondWithError(w, 404, "Event not found")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: looking up webhook event: %v", err)
+		respondWithError(w, 500, "Unable to replay event")
+		return
+	}
+	log.Printf("Admin %v replaying Polka event %v", requestPrincipal(r).UserID, event.EventID)
+	// Failures are stored on the event, which we send back either way
+	cfg.processPolkaEvent(ctx, event)
+
+	event, err = cfg.dbQuerries.GetWebhookEvent(ctx, key)
+	if err != nil {
+		log.Printf("ERROR: looking up webhook event: %v", err)
+		respondWithError(w, 500, "Unable to replay event")
+		return
+	}
+	respondWithJSON(w, 200, webhookEventResponse(event))
+}
+
+func webhookEventResponse(event database.WebhookEvent) WebhookEvent {
+	return WebhookEvent{
+		Provider:    event.Provider,
+		EventID:     event.EventID,
+		EventType:   event.EventType,
+		Payload:     event.Payload,
+		Status:      event.Status,
+		LastError:   event.LastError.String,
+		Attempts:    event.Attempts,
+		ReceivedAt:  event.ReceivedAt,
+		ProcessedAt: nullTime(event.ProcessedAt),
+	}
+}